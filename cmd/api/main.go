@@ -2,11 +2,23 @@ package main
 
 import (
 	"agregator/api/internal/pkg/app"
-	"log/slog"
+	"agregator/api/internal/pkg/logging"
+	"os"
 )
 
 func main() {
-	logger := slog.Default
-	app := app.New(logger())
+	logger := logging.New()
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "migrate":
+			os.Exit(runMigrate(logger, os.Args[2:]))
+		case "tags":
+			os.Exit(runTags(logger, os.Args[2:]))
+		case "openapi":
+			os.Exit(runOpenAPI(logger))
+		}
+	}
+
+	app := app.New(logger)
 	app.Run()
 }
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+
+	"agregator/api/internal/interfaces"
+	"agregator/api/internal/service/db"
+)
+
+const migrateUsage = "usage: api migrate up | down [N] | version"
+
+// runMigrate выполняет подкоманду migrate и возвращает код завершения процесса
+func runMigrate(logger interfaces.Logger, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return 2
+	}
+
+	ctx := context.Background()
+	database, err := db.New(logger)
+	if err != nil {
+		logger.Error("Error connecting to database", "error", err.Error())
+		return 1
+	}
+
+	switch args[0] {
+	case "up":
+		err = database.MigrateUp(ctx)
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				fmt.Fprintln(os.Stderr, migrateUsage)
+				return 2
+			}
+		}
+		err = database.MigrateDown(ctx, steps)
+	case "version":
+		var version int64
+		var dirty bool
+		version, dirty, err = database.SchemaVersion(ctx)
+		if err == nil {
+			fmt.Printf("version %d (dirty: %t), supported %d\n", version, dirty, db.LatestSchemaVersion())
+		}
+	default:
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return 2
+	}
+
+	if err != nil {
+		logger.Error("Migration failed", "error", err.Error())
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"os"
+
+	"agregator/api/internal/interfaces"
+	"agregator/api/internal/transport/rest"
+)
+
+// runOpenAPI печатает документ OpenAPI в stdout (например, для генерации клиентов).
+// Соответствие документа маршрутам проверяет тест пакета app.
+func runOpenAPI(logger interfaces.Logger) int {
+	spec, err := rest.OpenAPI()
+	if err != nil {
+		logger.Error("Error building OpenAPI document", "error", err.Error())
+		return 1
+	}
+	if _, err := os.Stdout.Write(append(spec, '\n')); err != nil {
+		return 1
+	}
+	return 0
+}
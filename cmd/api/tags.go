@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"time"
+
+	"agregator/api/internal/interfaces"
+	"agregator/api/internal/pkg/keywords"
+	"agregator/api/internal/service/db"
+)
+
+// runTags выделяет ключевые слова групп по TF-IDF и сохраняет их в таблицу tags.
+// Корпус для IDF — группы за -corpus, теги пересчитываются для групп за -update.
+// Предназначена для запуска по расписанию (cron, CronJob).
+func runTags(logger interfaces.Logger, args []string) int {
+	flags := flag.NewFlagSet("tags", flag.ContinueOnError)
+	corpus := flags.Duration("corpus", 7*24*time.Hour, "period of groups used as the IDF corpus")
+	update := flags.Duration("update", 48*time.Hour, "recompute tags for groups newer than this")
+	top := flags.Int("top", 10, "tags per group")
+	titleWeight := flags.Float64("title-weight", 3, "weight of title words relative to text words")
+	minDF := flags.Int("min-df", 2, "minimum number of groups a tag must appear in")
+	timeout := flags.Duration("timeout", 5*time.Minute, "timeout for each database operation")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	ctx := context.Background()
+	database, err := db.New(logger)
+	if err != nil {
+		logger.Error("Error connecting to database", "error", err.Error())
+		return 1
+	}
+	if err := database.CheckSchema(ctx); err != nil {
+		logger.Error("Unsupported schema", "error", err.Error())
+		return 1
+	}
+
+	now := time.Now()
+	corpusDocs, err := database.GetTagCorpus(ctx, now.Add(-*corpus), *timeout)
+	if err != nil {
+		logger.Error("Error loading corpus", "error", err.Error())
+		return 1
+	}
+
+	docs := make([]keywords.Document, len(corpusDocs))
+	for i, d := range corpusDocs {
+		docs[i] = keywords.Document{ID: d.ID, Title: d.Titles, Text: d.Text}
+	}
+	extracted := keywords.Extract(docs, keywords.Options{TopN: *top, TitleWeight: *titleWeight, MinDF: *minDF})
+
+	since := now.Add(-*update)
+	for _, d := range corpusDocs {
+		if d.Time.Before(since) {
+			delete(extracted, d.ID)
+		}
+	}
+	if err := database.SaveTags(ctx, extracted, *timeout); err != nil {
+		logger.Error("Error saving tags", "error", err.Error())
+		return 1
+	}
+	logger.Info("Tags updated", "corpus", len(corpusDocs), "groups", len(extracted))
+	return 0
+}
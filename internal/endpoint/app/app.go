@@ -16,8 +16,11 @@ type App struct {
 	api_v1 *gin.RouterGroup
 }
 
-func New() *App {
-	router := gin.Default()
+func New(middleware ...gin.HandlerFunc) *App {
+	// Логирование и восстановление после паники приходят через middleware, а не из gin.Default()
+	router := gin.New()
+	// Middleware нужно подключить до создания групп, иначе группы их не унаследуют
+	router.Use(middleware...)
 	api := router.Group("/api")
 	api_v1 := api.Group("/v1")
 
@@ -51,6 +54,11 @@ func New() *App {
 	}
 }
 
+// Get регистрирует обработчик в корне, вне /api (например, для проверок Kubernetes)
+func (a *App) Get(path string, fn gin.HandlerFunc) {
+	a.router.GET(path, fn)
+}
+
 func (a *App) GetAPI(path string, fn gin.HandlerFunc) {
 	a.api.GET(path, fn)
 }
@@ -63,6 +71,11 @@ func (a *App) PostV1(path string, fn gin.HandlerFunc) {
 	a.api_v1.POST(path, fn)
 }
 
+// Routes возвращает зарегистрированные маршруты
+func (a *App) Routes() gin.RoutesInfo {
+	return a.router.Routes()
+}
+
 func (a *App) Run(addr string) {
 	a.router.Run(addr)
 }
@@ -1,8 +1,26 @@
 package interfaces
 
+import "context"
+
 type Logger interface {
 	Info(msg string, args ...any)
 	Debug(msg string, args ...any)
 	Error(msg string, args ...any)
 	Warn(msg string, args ...any)
+
+	// Варианты с контекстом добавляют к записи данные запроса (request_id, trace_id)
+	InfoContext(ctx context.Context, msg string, args ...any)
+	DebugContext(ctx context.Context, msg string, args ...any)
+	ErrorContext(ctx context.Context, msg string, args ...any)
+	WarnContext(ctx context.Context, msg string, args ...any)
+}
+
+// Embedder превращает текст запроса в вектор той же модели, что и groups.embedding
+type Embedder interface {
+	Embed(ctx context.Context, text string) ([]float32, error)
+}
+
+// Classifier относит группу к рубрике по ее заголовкам и тексту; "" — рубрика не определена
+type Classifier interface {
+	Classify(ctx context.Context, title, text string) (string, error)
 }
@@ -1,37 +1,140 @@
 package db
 
 import (
-	"database/sql"
 	"time"
 )
 
 type List struct {
+	ID          uint64      `db:"id" json:"id"`
+	Time        time.Time   `db:"time" json:"date"`
+	Title       string      `db:"title" json:"title"`
+	Descritpion string      `db:"description" json:"description,omitempty"`
+	Enclosure   *string     `db:"enclosure" json:"enclosure,omitempty"`
+	IsRT        bool        `db:"is_rt" json:"isRT"`
+	SourceName  string      `db:"source_name" json:"sourceName"`
+	ViewsCount  uint64      `db:"views_count" json:"viewsCount"`      // Просмотры: сохраненные в БД плюс еще не сброшенные из Redis
+	Source      *SourceInfo `db:"source" json:"source,omitempty"`     // Метаданные основного источника
+	Score       *float64    `db:"score" json:"score,omitempty"`       // Сходство с исходной группой (только в похожих)
+	StoryID     *uint64     `db:"story_id" json:"storyId,omitempty"`  // Сюжет, частью которого является группа
+	Category    *string     `db:"category" json:"category,omitempty"` // Рубрика (politics, economy, ...)
+}
+
+// Story — сюжет: цепочка связанных групп, развивающаяся во времени
+type Story struct {
 	ID          uint64    `db:"id" json:"id"`
-	Time        time.Time `db:"time" json:"date"`
-	Title       string    `db:"title" json:"title"`
-	Descritpion string    `db:"description" json:"description,omitempty"`
-	Enclosure   *string   `db:"enclosure" json:"enclosure,omitempty"`
-	IsRT        bool      `db:"is_rt" json:"isRT"`
-	SourceName  string    `db:"source_name" json:"sourceName"`
+	Title       string    `db:"title" json:"title"`          // Заголовок первой группы сюжета
+	StartedAt   time.Time `db:"started_at" json:"startedAt"` // Время первой группы
+	UpdatedAt   time.Time `db:"updated_at" json:"updatedAt"` // Время последней группы
+	GroupsCount int       `db:"groups_count" json:"groupsCount"`
+	Groups      []List    `db:"-" json:"groups"` // Группы по возрастанию времени
+}
+
+// SourceInfo — метаданные источника из реестра sources
+type SourceInfo struct {
+	Name        string     `db:"name" json:"name"`                // Совпадает с feed.source_name
+	DisplayName string     `db:"display_name" json:"displayName"` // Название для показа
+	LogoURL     NullString `db:"logo_url" json:"logoUrl"`
+	SiteURL     NullString `db:"site_url" json:"siteUrl"`
+	Region      NullString `db:"region" json:"region"`
+	Language    NullString `db:"language" json:"language"`
+	Priority    int        `db:"priority" json:"priority"`      // Больше — важнее (например, при выборе обложки)
+	TrustLevel  int        `db:"trust_level" json:"trustLevel"` // Уровень доверия редакции к источнику
 }
 
 type Source struct {
-	Title       string         `json:"title" db:"source_title"`                       // Заголовок источника
-	SourceName  string         `json:"name" db:"source_name"`                         // Имя источника (например, "BBC News")
-	Time        time.Time      `json:"pubDate" db:"source_time"`                      // Время публикации источника
-	Link        string         `json:"link" db:"source_link"`                         // Ссылка на оригинальный источник
-	Description sql.NullString `json:"description,omitempty" db:"source_description"` // Описание из источника
-	FullText    sql.NullString `json:"full_text" db:"source_full_text"`               // Полный текст из источника
-	Enclosure   sql.NullString `json:"enclosure,omitempty" db:"source_enclosure"`     // Обложка из источника
+	ID          uint64      `json:"id" db:"source_id"`                             // Идентификатор записи ленты (feed.id)
+	Title       string      `json:"title" db:"source_title"`                       // Заголовок источника
+	SourceName  string      `json:"name" db:"source_name"`                         // Имя источника (например, "BBC News")
+	Time        time.Time   `json:"pubDate" db:"source_time"`                      // Время публикации источника
+	Link        string      `json:"link" db:"source_link"`                         // Ссылка на оригинальный источник
+	Description NullString  `json:"description,omitempty" db:"source_description"` // Описание из источника
+	FullText    NullString  `json:"full_text" db:"source_full_text"`               // Полный текст из источника
+	Enclosure   NullString  `json:"enclosure,omitempty" db:"source_enclosure"`     // Обложка из источника
+	Primary     bool        `json:"primary" db:"source_primary"`                   // Основной (представительный) источник группы
+	Info        *SourceInfo `json:"source,omitempty" db:"-"`                       // Метаданные источника из реестра
 }
 
 type News struct {
-	ID          uint64         `json:"id" db:"id"`
-	Title       string         `json:"title" db:"title"`                       // Это теперь заголовок ГРУППЫ
-	Description sql.NullString `json:"description,omitempty" db:"description"` // Описание ГРУППЫ
-	Time        time.Time      `json:"date" db:"time"`                         // Время создания ГРУППЫ
-	FullText    sql.NullString `json:"rewrite" db:"full_text"`                 // Полный текст ГРУППЫ (вероятно, rewrite)
-	Enclosure   sql.NullString `json:"enclosure,omitempty" db:"enclosure"`     // Обложка ГРУППЫ
-	Sources     []Source       `json:"sources" db:"-"`                         // Массив дочерних источников
-	ViewsCount  uint64         `json:"viewsCount" db:"views_count"`            // Счетчик просмотров группы
+	ID            uint64     `json:"id" db:"id"`
+	Title         string     `json:"title" db:"title"`                       // Заголовок ГРУППЫ
+	Description   NullString `json:"description,omitempty" db:"description"` // Описание ГРУППЫ
+	Time          time.Time  `json:"date" db:"time"`                         // Время создания ГРУППЫ
+	FullText      NullString `json:"rewrite" db:"full_text"`                 // Полный текст ГРУППЫ (rewrite)
+	Enclosure     NullString `json:"enclosure,omitempty" db:"enclosure"`     // Обложка ГРУППЫ
+	PrimaryFeedID uint64     `json:"primaryFeedId" db:"feed_id"`             // Основной источник группы (groups.feed_id)
+	Sources       []Source   `json:"sources" db:"-"`                         // Источники, новые первыми (все или первые N)
+	SourcesTotal  int        `json:"sourcesTotal" db:"-"`                    // Сколько всего источников в группе
+	SourcesCursor string     `json:"sourcesCursor,omitempty" db:"-"`         // Курсор следующей страницы /get/:id/sources, если вернулись не все
+	ViewsCount    uint64     `json:"viewsCount" db:"views_count"`            // Счетчик просмотров группы (БД + ожидающие сброса в Redis)
+	StoryID       *uint64    `json:"storyId,omitempty" db:"story_id"`        // Сюжет, частью которого является группа
+	Category      *string    `json:"category,omitempty" db:"category"`       // Рубрика группы
+}
+
+// CategoryCount — число групп рубрики за период
+type CategoryCount struct {
+	Category string `db:"category" json:"category"`
+	Groups   int    `db:"groups" json:"groups"`
+}
+
+// Coverage — развитие освещения группы: кто сообщил первым и как подключались остальные
+type Coverage struct {
+	GroupID       uint64          `json:"groupId"`
+	SourcesCount  int             `json:"sourcesCount"`            // Число разных источников
+	FirstReporter *CoverageEntry  `json:"firstReporter,omitempty"` // Самая ранняя публикация
+	Milestones    []Milestone     `json:"milestones"`              // Когда набралось N источников
+	Histogram     []HourBucket    `json:"histogram"`               // Подключение источников по часам; часы без новых источников пропускаются
+	TitleVariants []TitleVariant  `json:"titleVariants"`           // Заголовки, сгруппированные по сходству
+	Arrivals      []CoverageEntry `json:"arrivals"`                // Первая публикация каждого источника по времени
+}
+
+// CoverageEntry — публикация источника в группе
+type CoverageEntry struct {
+	FeedID     uint64    `db:"id" json:"id"`
+	SourceName string    `db:"source_name" json:"name"`
+	Title      string    `db:"title" json:"title"`
+	Time       time.Time `db:"time" json:"pubDate"`
+}
+
+// Milestone — момент, когда о событии написали Sources разных источников
+type Milestone struct {
+	Sources      int       `json:"sources"`
+	At           time.Time `json:"at"`
+	AfterSeconds int64     `json:"afterSeconds"` // Сколько прошло с первой публикации
+}
+
+// HourBucket — число источников, впервые написавших в течение часа Hour
+type HourBucket struct {
+	Hour  time.Time `json:"hour"`
+	Count int       `json:"count"`
+}
+
+// TitleVariant — группа похожих заголовков; Title — самый ранний из них
+type TitleVariant struct {
+	Title     string    `json:"title"`
+	Count     int       `json:"count"`
+	Sources   []string  `json:"sources"`
+	FirstSeen time.Time `json:"firstSeen"`
+}
+
+// Tag — ключевое слово или словосочетание группы
+type Tag struct {
+	Tag   string  `db:"tag" json:"tag"`     // Основы слов через пробел; используется в URL
+	Label string  `db:"label" json:"label"` // Написание для показа
+	Score float64 `db:"score" json:"score"` // Вес TF-IDF
+}
+
+// TrendingTag — тег с числом групп за последний период и за предыдущий такой же
+type TrendingTag struct {
+	Tag        string  `db:"tag" json:"tag"`
+	Label      string  `db:"label" json:"label"`
+	Groups     int     `db:"groups" json:"groups"`
+	PrevGroups int     `db:"prev_groups" json:"prevGroups"`
+	Score      float64 `db:"score" json:"score"` // Суммарный вес тега в группах периода
+}
+
+// SourcePage — страница источников группы
+type SourcePage struct {
+	Items      []Source `json:"items"`
+	Total      int      `json:"total"`
+	NextCursor string   `json:"nextCursor,omitempty"` // Пусто на последней странице
 }
@@ -0,0 +1,30 @@
+package db
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+)
+
+// NullString — строка, которая может быть NULL в БД. В JSON пишется так же, как
+// sql.NullString ({"String": ..., "Valid": ...}), — на этот формат рассчитывают клиенты.
+// Читается и из этого объекта, и из строки или null: так источники приходят из json_agg в Postgres.
+type NullString struct {
+	sql.NullString
+}
+
+func NewNullString(s string) NullString {
+	return NullString{sql.NullString{String: s, Valid: s != ""}}
+}
+
+func (s *NullString) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		*s = NullString{}
+		return nil
+	}
+	if len(data) > 0 && data[0] == '{' {
+		return json.Unmarshal(data, &s.NullString)
+	}
+	s.Valid = true
+	return json.Unmarshal(data, &s.String)
+}
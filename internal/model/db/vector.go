@@ -0,0 +1,74 @@
+package db
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Vector — значение pgvector. В БД передается в текстовом виде [1,2,3].
+type Vector []float32
+
+// IsZero сообщает, что у вектора нет направления: косинусное расстояние до него
+// в pgvector равно NaN, поэтому искать по такому вектору нельзя
+func (v Vector) IsZero() bool {
+	for _, x := range v {
+		if x != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (v *Vector) Scan(src any) error {
+	var s string
+	switch src := src.(type) {
+	case nil:
+		*v = nil
+		return nil
+	case []byte:
+		s = string(src)
+	case string:
+		s = src
+	default:
+		return fmt.Errorf("cannot scan %T into Vector", src)
+	}
+
+	s = strings.TrimSpace(s)
+	if len(s) < 2 || s[0] != '[' || s[len(s)-1] != ']' {
+		return fmt.Errorf("invalid vector %q", s)
+	}
+	s = s[1 : len(s)-1]
+	if s == "" {
+		*v = Vector{}
+		return nil
+	}
+	parts := strings.Split(s, ",")
+	out := make(Vector, len(parts))
+	for i, p := range parts {
+		f, err := strconv.ParseFloat(strings.TrimSpace(p), 32)
+		if err != nil {
+			return fmt.Errorf("invalid vector element %q: %w", p, err)
+		}
+		out[i] = float32(f)
+	}
+	*v = out
+	return nil
+}
+
+func (v Vector) Value() (driver.Value, error) {
+	if v == nil {
+		return nil, nil
+	}
+	var b strings.Builder
+	b.WriteByte('[')
+	for i, f := range v {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(strconv.FormatFloat(float64(f), 'g', -1, 32))
+	}
+	b.WriteByte(']')
+	return b.String(), nil
+}
@@ -3,33 +3,90 @@ package app
 import (
 	endpoint "agregator/api/internal/endpoint/app"
 	"agregator/api/internal/interfaces"
+	"agregator/api/internal/pkg/config"
+	"agregator/api/internal/pkg/openapi"
+	"agregator/api/internal/pkg/tracing"
+	"agregator/api/internal/transport/middleware"
 	api "agregator/api/internal/transport/rest"
-	"log"
+	"context"
+	"os"
+
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
 type App struct {
-	app *endpoint.App
-	api *api.API
+	app    *endpoint.App
+	api    *api.API
+	logger interfaces.Logger
+
+	shutdownTracing func(context.Context) error
 }
 
 func New(logger interfaces.Logger) *App {
+	shutdownTracing, err := tracing.Setup(context.Background())
+	if err != nil {
+		logger.Error("Error setting up tracing", "error", err.Error())
+		os.Exit(1)
+	}
 	api, err := api.New(logger)
 	if err != nil {
-		log.Fatal(err)
+		logger.Error("Error creating API", "error", err.Error())
+		os.Exit(1)
 	}
 	return &App{
-		app: endpoint.New(),
-		api: api,
+		app: endpoint.New(
+			otelgin.Middleware(tracing.ServiceName),
+			middleware.RequestID(),
+			middleware.AccessLog(logger, config.Float("LOG_SAMPLE_RATE", 1)),
+			middleware.Errors(logger),
+			middleware.Recovery(logger),
+		),
+		api:             api,
+		logger:          logger,
+		shutdownTracing: shutdownTracing,
 	}
 }
 
 func (a *App) Run() {
-	a.app.GetAPI("/ping", a.api.Check)
-	a.app.GetV1("/max", a.api.GetMax)
-	a.app.GetV1("/get/all", a.api.Get)
-	a.app.GetV1("/get/top", a.api.GetTop)
-	a.app.GetV1("/get/reg", a.api.GetRT)
-	a.app.GetV1("/get/similar/:id", a.api.GetSimilar)
-	a.app.GetV1("/get/:id", a.api.GetByID)
+	defer func() {
+		if err := a.shutdownTracing(context.Background()); err != nil {
+			a.logger.Error("Error shutting down tracing", "error", err.Error())
+		}
+	}()
+
+	routes(a.app, a.api)
 	a.app.Run(":8080")
 }
+
+// routes регистрирует маршруты API; каждый должен быть описан в api.Operations
+func routes(e *endpoint.App, h *api.API) {
+	e.Get("/healthz", h.Healthz)
+	e.Get("/readyz", h.Readyz)
+	e.GetAPI("/ping", h.Check)
+	e.GetAPI("/openapi.json", h.GetOpenAPI)
+	e.GetAPI("/docs", h.GetDocs)
+	e.GetV1("/max", h.GetMax)
+	e.GetV1("/get", h.GetBatch)
+	e.PostV1("/get", h.PostBatch)
+	e.GetV1("/get/all", h.Get)
+	e.GetV1("/get/top", h.GetTop)
+	e.GetV1("/get/reg", h.GetRT)
+	e.GetV1("/get/similar/:id", h.GetSimilar)
+	e.GetV1("/get/:id", h.GetByID)
+	e.GetV1("/get/:id/timeline", h.GetTimeline)
+	e.GetV1("/get/:id/sources", h.GetGroupSources)
+	e.GetV1("/categories", h.GetCategories)
+	e.GetV1("/search/semantic", h.SearchSemantic)
+	e.GetV1("/stories/:id", h.GetStory)
+	e.GetV1("/tags/trending", h.GetTrendingTags)
+	e.GetV1("/tags/:tag/groups", h.GetTagGroups)
+	e.GetV1("/sources", h.GetSources)
+	e.GetV1("/sources/:name/groups", h.GetSourceGroups)
+}
+
+// CheckRoutes сверяет маршруты с описанием OpenAPI (см. app_test.go); БД и Redis для этого не нужны
+func CheckRoutes() []string {
+	e := endpoint.New()
+	routes(e, nil)
+	return openapi.Drift(api.Operations, e.Routes())
+}
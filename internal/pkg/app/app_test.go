@@ -0,0 +1,15 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Каждый маршрут из routes должен быть описан в api.Operations и наоборот
+func TestRoutesMatchOpenAPI(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	for _, drift := range CheckRoutes() {
+		t.Error(drift)
+	}
+}
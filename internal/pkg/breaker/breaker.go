@@ -0,0 +1,122 @@
+package breaker
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrOpen возвращается, пока предохранитель разомкнут и запросы к зависимости не отправляются
+var ErrOpen = errors.New("circuit breaker is open")
+
+type State int
+
+const (
+	Closed   State = iota // Обычная работа
+	Open                  // Зависимость считается недоступной, запросы отклоняются
+	HalfOpen              // Пропускается один пробный запрос
+)
+
+func (s State) String() string {
+	switch s {
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// Breaker размыкается после threshold ошибок подряд и через cooldown
+// пропускает один пробный запрос; успешная проба снова замыкает цепь.
+type Breaker struct {
+	mu        sync.Mutex
+	state     State
+	failures  int
+	openedAt  time.Time
+	probing   bool
+	threshold int
+	cooldown  time.Duration
+}
+
+func New(threshold int, cooldown time.Duration) *Breaker {
+	if threshold < 1 {
+		threshold = 1
+	}
+	return &Breaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+	}
+}
+
+// Allow проверяет, можно ли выполнить запрос. Если запрос разрешен,
+// его результат обязательно нужно передать в Done.
+func (b *Breaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case Open:
+		if time.Since(b.openedAt) < b.cooldown {
+			return ErrOpen
+		}
+		b.state = HalfOpen
+		b.probing = true
+		return nil
+	case HalfOpen:
+		if b.probing {
+			return ErrOpen
+		}
+		b.probing = true
+		return nil
+	default:
+		return nil
+	}
+}
+
+// Done сообщает результат разрешенного запроса
+func (b *Breaker) Done(failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == HalfOpen {
+		b.probing = false
+		if failed {
+			b.state = Open
+			b.openedAt = time.Now()
+			return
+		}
+		b.state = Closed
+		b.failures = 0
+		return
+	}
+
+	if !failed {
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.state == Closed && b.failures >= b.threshold {
+		b.state = Open
+		b.openedAt = time.Now()
+	}
+}
+
+// Release освобождает разрешение без учета результата — например, если клиент
+// отменил запрос и по нему нельзя судить о состоянии зависимости
+func (b *Breaker) Release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == HalfOpen {
+		b.probing = false
+	}
+}
+
+// State возвращает текущее состояние предохранителя
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
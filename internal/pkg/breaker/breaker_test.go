@@ -0,0 +1,123 @@
+package breaker
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+const cooldown = 20 * time.Millisecond
+
+// fail проводит через b n неудачных запросов
+func fail(t *testing.T, b *Breaker, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		if err := b.Allow(); err != nil {
+			t.Fatalf("Allow() = %v before the breaker opened", err)
+		}
+		b.Done(true)
+	}
+}
+
+// open размыкает b и дожидается окончания cooldown
+func open(t *testing.T, b *Breaker) {
+	t.Helper()
+	fail(t, b, 3)
+	if b.State() != Open {
+		t.Fatalf("State() = %v, want open", b.State())
+	}
+	time.Sleep(cooldown + 5*time.Millisecond)
+}
+
+func TestOpensAfterThreshold(t *testing.T) {
+	b := New(3, time.Hour)
+	fail(t, b, 2)
+	if b.State() != Closed {
+		t.Fatalf("State() = %v after 2 failures, want closed", b.State())
+	}
+	fail(t, b, 1)
+	if b.State() != Open {
+		t.Fatalf("State() = %v after 3 failures, want open", b.State())
+	}
+	if err := b.Allow(); !errors.Is(err, ErrOpen) {
+		t.Errorf("Allow() during cooldown = %v, want ErrOpen", err)
+	}
+}
+
+func TestSuccessResetsFailures(t *testing.T) {
+	b := New(3, time.Hour)
+	fail(t, b, 2)
+	_ = b.Allow()
+	b.Done(false)
+	fail(t, b, 2)
+	if b.State() != Closed {
+		t.Errorf("State() = %v, want closed: failures must be consecutive", b.State())
+	}
+}
+
+func TestHalfOpenProbe(t *testing.T) {
+	tests := []struct {
+		name   string
+		failed bool
+		want   State
+	}{
+		{"probe success closes", false, Closed},
+		{"probe failure reopens", true, Open},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := New(3, cooldown)
+			open(t, b)
+
+			if err := b.Allow(); err != nil {
+				t.Fatalf("Allow() after cooldown = %v, want a probe", err)
+			}
+			if b.State() != HalfOpen {
+				t.Fatalf("State() = %v, want half-open", b.State())
+			}
+			// Пока проба не завершилась, остальные запросы отклоняются
+			if err := b.Allow(); !errors.Is(err, ErrOpen) {
+				t.Fatalf("second Allow() during probe = %v, want ErrOpen", err)
+			}
+
+			b.Done(tt.failed)
+			if b.State() != tt.want {
+				t.Errorf("State() = %v, want %v", b.State(), tt.want)
+			}
+		})
+	}
+}
+
+func TestReopenRestartsCooldown(t *testing.T) {
+	b := New(3, cooldown)
+	open(t, b)
+	_ = b.Allow()
+	b.Done(true)
+	if err := b.Allow(); !errors.Is(err, ErrOpen) {
+		t.Errorf("Allow() right after a failed probe = %v, want ErrOpen", err)
+	}
+}
+
+func TestReleaseFreesProbe(t *testing.T) {
+	b := New(3, cooldown)
+	open(t, b)
+	if err := b.Allow(); err != nil {
+		t.Fatal(err)
+	}
+	b.Release()
+	if b.State() != HalfOpen {
+		t.Fatalf("State() after Release = %v, want half-open", b.State())
+	}
+	if err := b.Allow(); err != nil {
+		t.Errorf("Allow() after Release = %v, want a new probe", err)
+	}
+}
+
+func TestReleaseWhenClosed(t *testing.T) {
+	b := New(1, time.Hour)
+	_ = b.Allow()
+	b.Release()
+	if b.State() != Closed {
+		t.Errorf("State() = %v, want closed: Release must not count as a failure", b.State())
+	}
+}
@@ -0,0 +1,61 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// String возвращает значение переменной окружения или значение по умолчанию
+func String(key, def string) string {
+	if val := strings.TrimSpace(os.Getenv(key)); val != "" {
+		return val
+	}
+	return def
+}
+
+// Int возвращает целое из переменной окружения; некорректное значение заменяется значением по умолчанию
+func Int(key string, def int) int {
+	val, err := strconv.Atoi(String(key, ""))
+	if err != nil {
+		return def
+	}
+	return val
+}
+
+// Uint возвращает беззнаковое целое из переменной окружения
+func Uint(key string, def uint64) uint64 {
+	val, err := strconv.ParseUint(String(key, ""), 10, 64)
+	if err != nil {
+		return def
+	}
+	return val
+}
+
+// Float возвращает число с плавающей точкой из переменной окружения
+func Float(key string, def float64) float64 {
+	val, err := strconv.ParseFloat(String(key, ""), 64)
+	if err != nil {
+		return def
+	}
+	return val
+}
+
+// Bool возвращает логическое значение из переменной окружения
+func Bool(key string, def bool) bool {
+	val, err := strconv.ParseBool(String(key, ""))
+	if err != nil {
+		return def
+	}
+	return val
+}
+
+// Duration возвращает длительность (формат time.ParseDuration) из переменной окружения
+func Duration(key string, def time.Duration) time.Duration {
+	val, err := time.ParseDuration(String(key, ""))
+	if err != nil {
+		return def
+	}
+	return val
+}
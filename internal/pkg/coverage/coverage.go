@@ -0,0 +1,101 @@
+// Package coverage строит хронологию освещения группы по ее публикациям.
+package coverage
+
+import (
+	"slices"
+	"time"
+
+	model "agregator/api/internal/model/db"
+	"agregator/api/internal/pkg/text"
+)
+
+// Milestones — для каких N считается время до N-го источника
+var Milestones = []int{2, 3, 5, 10, 25, 50, 100}
+
+// TitleThreshold — наименьшее сходство слов, при котором заголовки считаются вариантами одного
+const TitleThreshold = 0.5
+
+// Analyze строит отчет по публикациям группы. Источник учитывается по его первой публикации.
+func Analyze(groupID uint64, entries []model.CoverageEntry) model.Coverage {
+	entries = slices.Clone(entries)
+	slices.SortStableFunc(entries, func(a, b model.CoverageEntry) int {
+		return a.Time.Compare(b.Time)
+	})
+
+	report := model.Coverage{
+		GroupID:       groupID,
+		Milestones:    []model.Milestone{},
+		Histogram:     []model.HourBucket{},
+		TitleVariants: []model.TitleVariant{},
+		Arrivals:      []model.CoverageEntry{},
+	}
+	if len(entries) == 0 {
+		return report
+	}
+
+	seen := make(map[string]bool)
+	for _, e := range entries {
+		if !seen[e.SourceName] {
+			seen[e.SourceName] = true
+			report.Arrivals = append(report.Arrivals, e)
+		}
+	}
+	report.SourcesCount = len(report.Arrivals)
+	first := report.Arrivals[0]
+	report.FirstReporter = &first
+
+	for _, n := range Milestones {
+		if n > len(report.Arrivals) {
+			break
+		}
+		at := report.Arrivals[n-1].Time
+		report.Milestones = append(report.Milestones, model.Milestone{
+			Sources:      n,
+			At:           at,
+			AfterSeconds: int64(at.Sub(first.Time) / time.Second),
+		})
+	}
+
+	report.Histogram = histogram(report.Arrivals)
+	report.TitleVariants = titleVariants(entries)
+	return report
+}
+
+// histogram раскладывает появления источников по часам. Пустые часы не выводятся:
+// одна публикация с ошибочной датой иначе растянула бы ответ на годы пустых часов.
+func histogram(arrivals []model.CoverageEntry) []model.HourBucket {
+	var buckets []model.HourBucket
+	for _, a := range arrivals {
+		hour := a.Time.UTC().Truncate(time.Hour)
+		if n := len(buckets); n > 0 && buckets[n-1].Hour.Equal(hour) {
+			buckets[n-1].Count++
+			continue
+		}
+		buckets = append(buckets, model.HourBucket{Hour: hour, Count: 1})
+	}
+	return buckets
+}
+
+// titleVariants группирует заголовки всех публикаций по сходству слов
+func titleVariants(entries []model.CoverageEntry) []model.TitleVariant {
+	titles := make([]string, len(entries))
+	for i, e := range entries {
+		titles[i] = e.Title
+	}
+
+	var variants []model.TitleVariant
+	for _, cluster := range text.Cluster(titles, TitleThreshold) {
+		leader := entries[cluster[0]]
+		variant := model.TitleVariant{Title: leader.Title, Count: len(cluster), FirstSeen: leader.Time}
+		for _, i := range cluster {
+			if !slices.Contains(variant.Sources, entries[i].SourceName) {
+				variant.Sources = append(variant.Sources, entries[i].SourceName)
+			}
+		}
+		variants = append(variants, variant)
+	}
+	slices.SortStableFunc(variants, func(a, b model.TitleVariant) int {
+		return b.Count - a.Count
+	})
+	return variants
+}
@@ -0,0 +1,102 @@
+package coverage
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	model "agregator/api/internal/model/db"
+)
+
+func TestAnalyze(t *testing.T) {
+	start := time.Date(2026, 10, 1, 9, 15, 0, 0, time.UTC)
+	entries := []model.CoverageEntry{
+		{FeedID: 3, SourceName: "c", Title: "Центробанк снизил ключевую ставку до 16%", Time: start.Add(2 * time.Hour)},
+		{FeedID: 1, SourceName: "a", Title: "Центробанк снизил ключевую ставку", Time: start},
+		{FeedID: 2, SourceName: "b", Title: "Центробанк снизил ключевую ставку", Time: start.Add(10 * time.Minute)},
+		// Повторная публикация источника a не считается новым источником
+		{FeedID: 4, SourceName: "a", Title: "Инфляция замедлилась после решения ЦБ", Time: start.Add(3 * time.Hour)},
+	}
+
+	report := Analyze(42, entries)
+
+	if report.GroupID != 42 || report.SourcesCount != 3 {
+		t.Errorf("GroupID, SourcesCount = %d, %d, want 42, 3", report.GroupID, report.SourcesCount)
+	}
+	if report.FirstReporter == nil || report.FirstReporter.FeedID != 1 {
+		t.Errorf("FirstReporter = %+v, want feed 1", report.FirstReporter)
+	}
+	wantMilestones := []model.Milestone{
+		{Sources: 2, At: start.Add(10 * time.Minute), AfterSeconds: 600},
+		{Sources: 3, At: start.Add(2 * time.Hour), AfterSeconds: 7200},
+	}
+	if !reflect.DeepEqual(report.Milestones, wantMilestones) {
+		t.Errorf("Milestones = %+v, want %+v", report.Milestones, wantMilestones)
+	}
+	if len(report.TitleVariants) != 2 || report.TitleVariants[0].Count != 3 ||
+		!reflect.DeepEqual(report.TitleVariants[0].Sources, []string{"a", "b", "c"}) {
+		t.Errorf("TitleVariants = %+v, want the rate headline from a, b, c first", report.TitleVariants)
+	}
+	// Исходный срез не переупорядочивается
+	if entries[0].FeedID != 3 {
+		t.Error("Analyze sorted the caller's entries")
+	}
+}
+
+func TestAnalyzeEmpty(t *testing.T) {
+	report := Analyze(1, nil)
+	if report.FirstReporter != nil || report.Histogram == nil || report.Milestones == nil ||
+		report.TitleVariants == nil || report.Arrivals == nil {
+		t.Errorf("Analyze(nil) = %+v, want empty non-nil lists", report)
+	}
+}
+
+func TestHistogram(t *testing.T) {
+	hour := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) model.CoverageEntry { return model.CoverageEntry{Time: hour.Add(d)} }
+
+	tests := []struct {
+		name     string
+		arrivals []model.CoverageEntry
+		want     []model.HourBucket
+	}{
+		{
+			name:     "same hour",
+			arrivals: []model.CoverageEntry{at(5 * time.Minute), at(55 * time.Minute)},
+			want:     []model.HourBucket{{Hour: hour, Count: 2}},
+		},
+		{
+			name:     "empty hours are skipped",
+			arrivals: []model.CoverageEntry{at(0), at(time.Hour), at(4*time.Hour + 30*time.Minute)},
+			want: []model.HourBucket{
+				{Hour: hour, Count: 1},
+				{Hour: hour.Add(time.Hour), Count: 1},
+				{Hour: hour.Add(4 * time.Hour), Count: 1},
+			},
+		},
+		{
+			// Публикация с датой на годы позже не раздувает ответ пустыми часами
+			name:     "misdated arrival",
+			arrivals: []model.CoverageEntry{at(0), at(5 * 365 * 24 * time.Hour)},
+			want: []model.HourBucket{
+				{Hour: hour, Count: 1},
+				{Hour: hour.Add(5 * 365 * 24 * time.Hour), Count: 1},
+			},
+		},
+		{
+			name: "hours are taken in UTC",
+			arrivals: []model.CoverageEntry{
+				{Time: hour.In(time.FixedZone("MSK", 3*60*60))},
+				{Time: hour.Add(20 * time.Minute)},
+			},
+			want: []model.HourBucket{{Hour: hour, Count: 2}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := histogram(tt.arrivals); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("histogram() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+// Package keywords выделяет ключевые слова и словосочетания групп по TF-IDF.
+package keywords
+
+import (
+	"math"
+	"slices"
+	"strings"
+
+	model "agregator/api/internal/model/db"
+	"agregator/api/internal/pkg/text"
+)
+
+// Document — тексты одной группы
+type Document struct {
+	ID    uint64
+	Title string // Заголовки публикаций группы
+	Text  string // Описания и полные тексты
+}
+
+// Options — параметры выделения
+type Options struct {
+	TopN        int     // Сколько тегов оставить на группу
+	TitleWeight float64 // Во сколько раз слово заголовка весомее слова текста
+	MinDF       int     // В скольких группах корпуса должен встречаться тег
+}
+
+// Key приводит слово или словосочетание к виду, в котором теги хранятся в БД
+func Key(phrase string) string {
+	var stems []string
+	for _, w := range text.Words(phrase) {
+		if !w.Stop {
+			stems = append(stems, w.Stem)
+		}
+	}
+	return strings.Join(stems, " ")
+}
+
+type counter struct {
+	terms    map[string]float64
+	surfaces map[string]map[string]int
+}
+
+// add учитывает слова и пары соседних значимых слов
+func (c counter) add(s string, weight float64) {
+	var prev *text.Word
+	for _, w := range text.Words(s) {
+		if w.Stop {
+			prev = nil
+			continue
+		}
+		c.count(w.Stem, w.Surface, weight)
+		if prev != nil {
+			c.count(prev.Stem+" "+w.Stem, prev.Surface+" "+w.Surface, weight)
+		}
+		prev = &w
+	}
+}
+
+func (c counter) count(key, surface string, weight float64) {
+	c.terms[key] += weight
+	if c.surfaces[key] == nil {
+		c.surfaces[key] = make(map[string]int)
+	}
+	c.surfaces[key][surface]++
+}
+
+// Extract считает TF-IDF по корпусу docs и возвращает лучшие теги каждой группы.
+// Подпись тега — самое частое его написание в корпусе.
+func Extract(docs []Document, opts Options) map[uint64][]model.Tag {
+	surfaces := make(map[string]map[string]int)
+	perDoc := make([]map[string]float64, len(docs))
+	df := make(map[string]int)
+	for i, d := range docs {
+		c := counter{terms: make(map[string]float64), surfaces: surfaces}
+		c.add(d.Title, opts.TitleWeight)
+		c.add(d.Text, 1)
+		perDoc[i] = c.terms
+		for key := range c.terms {
+			df[key]++
+		}
+	}
+
+	labels := make(map[string]string, len(surfaces))
+	for key, forms := range surfaces {
+		best, bestCount := "", 0
+		for form, n := range forms {
+			if n > bestCount || (n == bestCount && form < best) {
+				best, bestCount = form, n
+			}
+		}
+		labels[key] = best
+	}
+
+	n := float64(len(docs))
+	result := make(map[uint64][]model.Tag, len(docs))
+	for i, d := range docs {
+		var total float64
+		for _, w := range perDoc[i] {
+			total += w
+		}
+		var scored []model.Tag
+		for key, w := range perDoc[i] {
+			if df[key] < opts.MinDF {
+				continue
+			}
+			idf := math.Log((n+1)/(float64(df[key])+1)) + 1
+			scored = append(scored, model.Tag{Tag: key, Label: labels[key], Score: w / total * idf})
+		}
+		slices.SortFunc(scored, func(a, b model.Tag) int {
+			if a.Score != b.Score {
+				if a.Score > b.Score {
+					return -1
+				}
+				return 1
+			}
+			// При равном весе словосочетание информативнее отдельного слова
+			if na, nb := strings.Count(a.Tag, " "), strings.Count(b.Tag, " "); na != nb {
+				return nb - na
+			}
+			return strings.Compare(a.Tag, b.Tag)
+		})
+		result[d.ID] = top(scored, opts.TopN)
+	}
+	return result
+}
+
+// top берет n лучших тегов, пропуская слова, уже вошедшие в выбранные словосочетания
+func top(scored []model.Tag, n int) []model.Tag {
+	var picked []model.Tag
+	covered := make(map[string]bool)
+	for _, t := range scored {
+		if len(picked) >= n {
+			break
+		}
+		if covered[t.Tag] {
+			continue
+		}
+		picked = append(picked, t)
+		for _, stem := range strings.Fields(t.Tag) {
+			covered[stem] = true
+		}
+	}
+	return picked
+}
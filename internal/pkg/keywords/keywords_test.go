@@ -0,0 +1,110 @@
+package keywords
+
+import (
+	"reflect"
+	"testing"
+
+	model "agregator/api/internal/model/db"
+)
+
+func TestKey(t *testing.T) {
+	tests := []struct {
+		phrase string
+		want   string
+	}{
+		{"Выборы Президента", "выбор президент"},
+		{"выборов  президента", "выбор президент"},
+		{"Санкт-Петербург", "санкт петербург"},
+		{"NASA", "nasa"},
+		// Стоп-слова, числа и короткие слова отбрасываются
+		{"это было в 2024 году", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.phrase, func(t *testing.T) {
+			if got := Key(tt.phrase); got != tt.want {
+				t.Errorf("Key(%q) = %q, want %q", tt.phrase, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtract(t *testing.T) {
+	docs := []Document{
+		{ID: 1, Title: "Выборы президента во Франции", Text: "Во Франции прошли выборы президента. Явка на выборах выросла."},
+		{ID: 2, Title: "Выборы президента в США", Text: "Кандидаты в президенты США провели дебаты."},
+		{ID: 3, Title: "Футбольный матч сборной", Text: "Сборная выиграла матч."},
+	}
+
+	tests := []struct {
+		name string
+		opts Options
+		want map[uint64][]string
+	}{
+		{
+			name: "best terms per group",
+			opts: Options{TopN: 2, TitleWeight: 2, MinDF: 1},
+			want: map[uint64][]string{1: {"выбор", "франц"}, 2: {"сша", "президент"}, 3: {"матч", "сборн"}},
+		},
+		{
+			// Теги, встречающиеся только в одной группе, отсекаются
+			name: "min document frequency",
+			opts: Options{TopN: 5, TitleWeight: 2, MinDF: 2},
+			want: map[uint64][]string{1: {"выбор", "выбор президент"}, 2: {"президент", "выбор президент"}, 3: nil},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := make(map[uint64][]string)
+			for id, tags := range Extract(docs, tt.opts) {
+				var names []string
+				for _, tag := range tags {
+					names = append(names, tag.Tag)
+				}
+				got[id] = names
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Extract() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractLabel(t *testing.T) {
+	docs := []Document{
+		{ID: 1, Title: "Выборы президента", Text: "выборы президента"},
+		{ID: 2, Title: "Выборы президента", Text: "выборов президента"},
+	}
+	for _, tag := range Extract(docs, Options{TopN: 3, TitleWeight: 1, MinDF: 1})[1] {
+		if tag.Tag == "выбор президент" && tag.Label != "Выборы президента" {
+			t.Errorf("label = %q, want the most frequent form %q", tag.Label, "Выборы президента")
+		}
+	}
+}
+
+func TestTop(t *testing.T) {
+	scored := []model.Tag{
+		{Tag: "выбор президент", Score: 3},
+		{Tag: "выбор", Score: 2},
+		{Tag: "франц", Score: 1},
+		{Tag: "президент", Score: 0.5},
+	}
+	tests := []struct {
+		n    int
+		want []string
+	}{
+		// Слова уже выбранного словосочетания пропускаются
+		{3, []string{"выбор президент", "франц"}},
+		{1, []string{"выбор президент"}},
+		{0, nil},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, tag := range top(scored, tt.n) {
+			got = append(got, tag.Tag)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("top(%d) = %v, want %v", tt.n, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,451 @@
+// Package openapi строит документ OpenAPI 3.1 по описанию операций и Go-типам:
+// параметры берутся из тегов form/uri/binding, схемы ответов — из JSON-тегов.
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Operation описывает один маршрут. Path записывается как в Gin (/get/:id).
+type Operation struct {
+	Method      string
+	Path        string
+	ID          string // operationId
+	Summary     string
+	Description string
+	Tags        []string
+	Params      []any // Значения структур с тегами form (query) и uri (path)
+	Body        any   // Значение типа JSON-тела запроса
+	Response    any   // Значение типа успешного ответа; nil — тело не описывается
+}
+
+// Spec — общие параметры документа
+type Spec struct {
+	Title       string
+	Version     string
+	Description string
+	Problem     any // Тип тела ответа с ошибкой (application/problem+json)
+
+	// Types задает схемы для типов с собственным JSON-представлением
+	Types map[reflect.Type]*Schema
+	// Rules дополняет схему параметра по нестандартному правилу binding (имя правила — ключ)
+	Rules map[string]func(param string, s *Schema)
+	// Descriptions — описания полей вида "News.rewrite" (тип Go и JSON-имя)
+	Descriptions map[string]string
+}
+
+// Schema — JSON Schema в подмножестве, нужном для описания API
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 any                `json:"type,omitempty"` // Строка или массив типов (с "null")
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Enum                 []any              `json:"enum,omitempty"`
+	Default              any                `json:"default,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	AnyOf                []*Schema          `json:"anyOf,omitempty"`
+}
+
+// Document — корень документа OpenAPI
+type Document struct {
+	OpenAPI    string                           `json:"openapi"`
+	Info       Info                             `json:"info"`
+	Paths      map[string]map[string]*operation `json:"paths"`
+	Components Components                       `json:"components"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas"`
+}
+
+type operation struct {
+	ID          string               `json:"operationId"`
+	Summary     string               `json:"summary,omitempty"`
+	Description string               `json:"description,omitempty"`
+	Tags        []string             `json:"tags,omitempty"`
+	Parameters  []parameter          `json:"parameters,omitempty"`
+	RequestBody *body                `json:"requestBody,omitempty"`
+	Responses   map[string]*response `json:"responses"`
+}
+
+type parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required,omitempty"`
+	Explode  *bool   `json:"explode,omitempty"`
+	Schema   *Schema `json:"schema"`
+}
+
+type body struct {
+	Required bool             `json:"required"`
+	Content  map[string]media `json:"content"`
+}
+
+type response struct {
+	Description string           `json:"description"`
+	Content     map[string]media `json:"content,omitempty"`
+}
+
+type media struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Build собирает документ по списку операций
+func Build(spec Spec, ops []Operation) *Document {
+	g := &generator{spec: spec, schemas: make(map[string]*Schema)}
+	doc := &Document{
+		OpenAPI: "3.1.0",
+		Info:    Info{Title: spec.Title, Version: spec.Version, Description: spec.Description},
+		Paths:   make(map[string]map[string]*operation),
+	}
+
+	var problem *Schema
+	if spec.Problem != nil {
+		problem = g.schema(reflect.TypeOf(spec.Problem), false)
+	}
+	for _, op := range ops {
+		out := &operation{
+			ID:          op.ID,
+			Summary:     op.Summary,
+			Description: op.Description,
+			Tags:        op.Tags,
+			Responses:   make(map[string]*response),
+		}
+		for _, p := range op.Params {
+			out.Parameters = append(out.Parameters, g.parameters(reflect.TypeOf(p))...)
+		}
+		if op.Body != nil {
+			out.RequestBody = &body{
+				Required: true,
+				Content:  map[string]media{"application/json": {Schema: g.schema(reflect.TypeOf(op.Body), true)}},
+			}
+		}
+		ok := &response{Description: "OK"}
+		if op.Response != nil {
+			ok.Content = map[string]media{"application/json": {Schema: g.schema(reflect.TypeOf(op.Response), false)}}
+		}
+		out.Responses["200"] = ok
+		if problem != nil {
+			out.Responses["default"] = &response{
+				Description: "Error",
+				Content:     map[string]media{"application/problem+json": {Schema: problem}},
+			}
+		}
+
+		path := Path(op.Path)
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = make(map[string]*operation)
+		}
+		doc.Paths[path][strings.ToLower(op.Method)] = out
+	}
+	doc.Components.Schemas = g.schemas
+	return doc
+}
+
+// Path переводит путь Gin (/get/:id, /files/*path) в шаблон OpenAPI (/get/{id})
+func Path(ginPath string) string {
+	parts := strings.Split(ginPath, "/")
+	for i, part := range parts {
+		if strings.HasPrefix(part, ":") || strings.HasPrefix(part, "*") {
+			parts[i] = "{" + part[1:] + "}"
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+// Drift сравнивает описанные операции с маршрутами Gin и возвращает расхождения
+// вида "GET /api/v1/x: not documented" или "...: not registered"
+func Drift(ops []Operation, routes gin.RoutesInfo) []string {
+	documented := make(map[string]bool, len(ops))
+	for _, op := range ops {
+		documented[op.Method+" "+op.Path] = true
+	}
+	var drift []string
+	for _, r := range routes {
+		key := r.Method + " " + r.Path
+		if !documented[key] {
+			drift = append(drift, key+": not documented")
+		}
+		delete(documented, key)
+	}
+	for key := range documented {
+		drift = append(drift, key+": not registered")
+	}
+	sort.Strings(drift)
+	return drift
+}
+
+type generator struct {
+	spec    Spec
+	schemas map[string]*Schema // components/schemas по имени типа
+}
+
+var timeType = reflect.TypeFor[time.Time]()
+
+// schema возвращает схему типа; именованные структуры выносятся в components.
+// Для тел запросов (request) обязательность полей берется из binding:"required",
+// для ответов обязательны все поля без omitempty.
+func (g *generator) schema(t reflect.Type, request bool) *Schema {
+	if s, ok := g.spec.Types[t]; ok {
+		copied := *s
+		return &copied
+	}
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case reflect.TypeFor[json.RawMessage]():
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return nullable(g.schema(t.Elem(), request))
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &Schema{Type: "integer", Format: intFormat(t)}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer", Format: intFormat(t), Minimum: ptr(0.0)}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: g.schema(t.Elem(), request)}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schema(t.Elem(), request)}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.object(t, request)
+		}
+		if _, ok := g.schemas[t.Name()]; !ok {
+			g.schemas[t.Name()] = &Schema{} // Заглушка на случай рекурсивных типов
+			g.schemas[t.Name()] = g.object(t, request)
+		}
+		return &Schema{Ref: "#/components/schemas/" + t.Name()}
+	default:
+		return &Schema{}
+	}
+}
+
+// object строит схему структуры по JSON-тегам, раскрывая встроенные структуры
+func (g *generator) object(t reflect.Type, request bool) *Schema {
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	g.fields(t, request, s)
+	return s
+}
+
+func (g *generator) fields(t reflect.Type, request bool, s *Schema) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			g.fields(f.Type, request, s)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		prop := g.schema(f.Type, request)
+		applyRules(prop, f.Tag.Get("binding"), g.spec.Rules)
+		if desc := g.spec.Descriptions[t.Name()+"."+name]; desc != "" {
+			prop.Description = desc // У $ref соседние ключи допустимы начиная с 3.1
+		}
+		s.Properties[name] = prop
+
+		required := !strings.Contains(opts, "omitempty")
+		if request {
+			required = hasRule(f.Tag.Get("binding"), "required")
+		}
+		if required {
+			s.Required = append(s.Required, name)
+		}
+	}
+}
+
+// parameters разбирает поля структуры с тегами form (query) и uri (path)
+func (g *generator) parameters(t reflect.Type) []parameter {
+	var params []parameter
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous && f.Type.Kind() == reflect.Struct {
+			params = append(params, g.parameters(f.Type)...)
+			continue
+		}
+
+		in, tag := "query", f.Tag.Get("form")
+		if uri := f.Tag.Get("uri"); uri != "" {
+			in, tag = "path", uri
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if name == "" || name == "-" {
+			continue
+		}
+
+		s := g.schema(f.Type, true)
+		if f.Type.Kind() == reflect.Pointer {
+			s = g.schema(f.Type.Elem(), true)
+		}
+		binding := f.Tag.Get("binding")
+		applyRules(s, binding, g.spec.Rules)
+		if def, ok := strings.CutPrefix(opts, "default="); ok {
+			s.Default = parseDefault(def, f.Type)
+		}
+
+		p := parameter{Name: name, In: in, Required: in == "path" || hasRule(binding, "required"), Schema: s}
+		if f.Type.Kind() == reflect.Slice {
+			// Повтор параметра (?source=a&source=b); значения через запятую тоже принимаются
+			p.Explode = ptr(true)
+		}
+		params = append(params, p)
+	}
+	return params
+}
+
+// applyRules переносит правила binding (min, max, oneof, dive и пользовательские) в схему
+func applyRules(s *Schema, binding string, custom map[string]func(string, *Schema)) {
+	if binding == "" {
+		return
+	}
+	target := s
+	for _, rule := range strings.Split(binding, ",") {
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "dive":
+			if target.Items == nil {
+				return
+			}
+			target = target.Items
+		case "min", "max":
+			n, err := strconv.Atoi(param)
+			if err != nil {
+				continue
+			}
+			setBound(target, name == "min", n)
+		case "oneof":
+			for _, v := range strings.Fields(param) {
+				target.Enum = append(target.Enum, v)
+			}
+		default:
+			if fn, ok := custom[name]; ok {
+				fn(param, target)
+			}
+		}
+	}
+}
+
+// setBound задает границу по смыслу типа: длина строки, число элементов или значение
+func setBound(s *Schema, min bool, n int) {
+	switch s.Type {
+	case "string":
+		if min {
+			s.MinLength = &n
+		} else {
+			s.MaxLength = &n
+		}
+	case "array":
+		if min {
+			s.MinItems = &n
+		} else {
+			s.MaxItems = &n
+		}
+	default:
+		v := float64(n)
+		if min {
+			s.Minimum = &v
+		} else {
+			s.Maximum = &v
+		}
+	}
+}
+
+func hasRule(binding, rule string) bool {
+	for _, r := range strings.Split(binding, ",") {
+		if r == "dive" {
+			return false
+		}
+		if r == rule {
+			return true
+		}
+	}
+	return false
+}
+
+func parseDefault(def string, t reflect.Type) any {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if n, err := strconv.ParseInt(def, 10, 64); err == nil {
+			return n
+		}
+	case reflect.Float32, reflect.Float64:
+		if f, err := strconv.ParseFloat(def, 64); err == nil {
+			return f
+		}
+	case reflect.Bool:
+		if b, err := strconv.ParseBool(def); err == nil {
+			return b
+		}
+	}
+	return def
+}
+
+// nullable разрешает null; для ссылок на components используется anyOf
+func nullable(s *Schema) *Schema {
+	switch typ := s.Type.(type) {
+	case string:
+		s.Type = []string{typ, "null"}
+		return s
+	case []string:
+		return s
+	}
+	if s.Ref == "" {
+		return s
+	}
+	return &Schema{AnyOf: []*Schema{s, {Type: "null"}}}
+}
+
+func intFormat(t reflect.Type) string {
+	if t.Bits() == 64 {
+		return "int64"
+	}
+	return "int32"
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,88 @@
+// Package ranking содержит функции переупорядочивания результатов поиска.
+package ranking
+
+import (
+	"cmp"
+	"math"
+	"slices"
+)
+
+// Cosine возвращает косинусное сходство векторов; для нулевых или разной длины — 0
+func Cosine(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
+
+// MMR выбирает до k кандидатов по maximal marginal relevance:
+// на каждом шаге берется кандидат с наибольшим lambda*relevance - (1-lambda)*max(сходство с уже выбранными).
+// lambda = 1 — чистая релевантность, lambda = 0 — максимальное разнообразие.
+// Возвращает индексы выбранных кандидатов в порядке выбора.
+func MMR(relevance []float64, vectors [][]float32, lambda float64, k int) []int {
+	n := len(relevance)
+	if k > n {
+		k = n
+	}
+	selected := make([]int, 0, k)
+	used := make([]bool, n)
+	// Наибольшее сходство каждого кандидата с уже выбранными
+	redundancy := make([]float64, n)
+
+	for len(selected) < k {
+		best, bestScore := -1, math.Inf(-1)
+		for i := 0; i < n; i++ {
+			if used[i] {
+				continue
+			}
+			score := lambda * relevance[i]
+			if len(selected) > 0 {
+				score -= (1 - lambda) * redundancy[i]
+			}
+			if score > bestScore {
+				best, bestScore = i, score
+			}
+		}
+		used[best] = true
+		selected = append(selected, best)
+
+		for i := 0; i < n; i++ {
+			if used[i] {
+				continue
+			}
+			if sim := Cosine(vectors[i], vectors[best]); len(selected) == 1 || sim > redundancy[i] {
+				redundancy[i] = sim
+			}
+		}
+	}
+	return selected
+}
+
+// RRF объединяет несколько ранжированных списков идентификаторов reciprocal rank fusion:
+// score(id) = сумма 1/(k + rank) по спискам, rank считается с 1. Возвращает идентификаторы
+// по убыванию score (при равенстве — в порядке первого появления) и сами значения score.
+func RRF(k float64, lists ...[]uint64) ([]uint64, map[uint64]float64) {
+	scores := make(map[uint64]float64)
+	var order []uint64
+	for _, list := range lists {
+		for rank, id := range list {
+			if _, ok := scores[id]; !ok {
+				order = append(order, id)
+			}
+			scores[id] += 1 / (k + float64(rank+1))
+		}
+	}
+	slices.SortStableFunc(order, func(a, b uint64) int {
+		return cmp.Compare(scores[b], scores[a])
+	})
+	return order, scores
+}
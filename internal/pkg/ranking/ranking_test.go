@@ -0,0 +1,89 @@
+package ranking
+
+import (
+	"math"
+	"slices"
+	"testing"
+)
+
+func TestCosine(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b []float32
+		want float64
+	}{
+		{"same direction", []float32{1, 2}, []float32{2, 4}, 1},
+		{"orthogonal", []float32{1, 0}, []float32{0, 1}, 0},
+		{"opposite", []float32{1, 0}, []float32{-1, 0}, -1},
+		{"zero vector", []float32{0, 0}, []float32{1, 0}, 0},
+		{"different length", []float32{1}, []float32{1, 0}, 0},
+		{"empty", nil, nil, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Cosine(tt.a, tt.b); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("Cosine(%v, %v) = %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMMR(t *testing.T) {
+	// 0 и 1 — почти дубликаты, 2 — другая тема с чуть меньшей релевантностью
+	relevance := []float64{0.9, 0.89, 0.8}
+	vectors := [][]float32{{1, 0}, {1, 0.01}, {0, 1}}
+
+	tests := []struct {
+		name      string
+		relevance []float64
+		vectors   [][]float32
+		lambda    float64
+		k         int
+		want      []int
+	}{
+		{"lambda 1 keeps relevance order", relevance, vectors, 1, 3, []int{0, 1, 2}},
+		{"balanced lambda skips the duplicate", relevance, vectors, 0.5, 3, []int{0, 2, 1}},
+		{"lambda 0 starts from the first candidate", relevance, vectors, 0, 2, []int{0, 2}},
+		{"k above candidates", relevance, vectors, 0.5, 10, []int{0, 2, 1}},
+		{"k zero", relevance, vectors, 0.5, 0, []int{}},
+		{"no candidates", nil, nil, 0.5, 5, []int{}},
+		{"equal scores keep input order", []float64{0.5, 0.5}, [][]float32{{1, 0}, {0, 1}}, 1, 2, []int{0, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MMR(tt.relevance, tt.vectors, tt.lambda, tt.k); !slices.Equal(got, tt.want) {
+				t.Errorf("MMR() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRRF(t *testing.T) {
+	tests := []struct {
+		name  string
+		lists [][]uint64
+		want  []uint64
+	}{
+		{"single list keeps order", [][]uint64{{3, 1, 2}}, []uint64{3, 1, 2}},
+		{"found in both lists wins", [][]uint64{{1, 2}, {3, 2}}, []uint64{2, 1, 3}},
+		// 1 и 3 — оба первые в своем списке: равный score, порядок первого появления
+		{"tie keeps first appearance", [][]uint64{{1, 2}, {3, 4}}, []uint64{1, 3, 2, 4}},
+		{"empty lists", [][]uint64{{}, nil}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _ := RRF(60, tt.lists...)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("RRF() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	_, scores := RRF(60, []uint64{7, 8}, []uint64{8})
+	if want := 1.0 / 61; math.Abs(scores[7]-want) > 1e-12 {
+		t.Errorf("score of rank 1 = %v, want %v", scores[7], want)
+	}
+	if want := 1.0/62 + 1.0/61; math.Abs(scores[8]-want) > 1e-12 {
+		t.Errorf("score of ranks 2 and 1 = %v, want %v", scores[8], want)
+	}
+}
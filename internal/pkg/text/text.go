@@ -0,0 +1,64 @@
+// Package text содержит простую обработку текстов новостей: разбиение на слова и сравнение.
+package text
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Tokens разбивает текст на слова в нижнем регистре, отбрасывая знаки препинания
+func Tokens(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// Jaccard возвращает долю общих слов двух наборов (0 — нет общих, 1 — совпадают)
+func Jaccard(a, b []string) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 1
+	}
+	set := make(map[string]bool, len(a))
+	for _, t := range a {
+		set[t] = true
+	}
+	union := len(set)
+	var common int
+	seen := make(map[string]bool, len(b))
+	for _, t := range b {
+		if seen[t] {
+			continue
+		}
+		seen[t] = true
+		if set[t] {
+			common++
+		} else {
+			union++
+		}
+	}
+	return float64(common) / float64(union)
+}
+
+// Cluster группирует тексты жадно по порядку: текст попадает в первую группу,
+// с первым текстом которой сходство по Jaccard не меньше threshold, иначе открывает новую.
+// Возвращает индексы текстов по группам.
+func Cluster(texts []string, threshold float64) [][]int {
+	var clusters [][]int
+	var leaders [][]string
+	for i, t := range texts {
+		tokens := Tokens(t)
+		placed := false
+		for c, leader := range leaders {
+			if Jaccard(leader, tokens) >= threshold {
+				clusters[c] = append(clusters[c], i)
+				placed = true
+				break
+			}
+		}
+		if !placed {
+			clusters = append(clusters, []int{i})
+			leaders = append(leaders, tokens)
+		}
+	}
+	return clusters
+}
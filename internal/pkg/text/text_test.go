@@ -0,0 +1,63 @@
+package text
+
+import (
+	"math"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestTokens(t *testing.T) {
+	got := Tokens("Путин, «Газпром» и G7: 2024-й год!")
+	want := []string{"путин", "газпром", "и", "g7", "2024", "й", "год"}
+	if !slices.Equal(got, want) {
+		t.Errorf("Tokens() = %q, want %q", got, want)
+	}
+}
+
+func TestJaccard(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b []string
+		want float64
+	}{
+		{"both empty", nil, nil, 1},
+		{"one empty", []string{"a"}, nil, 0},
+		{"same", []string{"a", "b"}, []string{"b", "a"}, 1},
+		{"half", []string{"a", "b"}, []string{"b", "c"}, 1.0 / 3},
+		{"duplicates count once", []string{"a", "a", "b"}, []string{"a", "a"}, 0.5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Jaccard(tt.a, tt.b); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("Jaccard(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCluster(t *testing.T) {
+	titles := []string{
+		"Центробанк снизил ключевую ставку",
+		"Центробанк снизил ключевую ставку до 16%",
+		"Центробанк снизил ключевую ставку!",
+		"В Москве выпал первый снег",
+		"Первый снег выпал в Москве",
+	}
+	tests := []struct {
+		name      string
+		threshold float64
+		want      [][]int
+	}{
+		{"variants of two events", 0.5, [][]int{{0, 1, 2}, {3, 4}}},
+		{"exact wording only", 1, [][]int{{0, 2}, {1}, {3, 4}}},
+		{"everything together", 0, [][]int{{0, 1, 2, 3, 4}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Cluster(titles, tt.threshold); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Cluster() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
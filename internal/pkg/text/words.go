@@ -0,0 +1,70 @@
+package text
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/kljensen/snowball/russian"
+)
+
+// Word — слово текста в исходном написании и его основа
+type Word struct {
+	Surface string
+	Stem    string
+	Stop    bool // Стоп-слово, число или слишком короткое слово
+}
+
+// Words разбивает текст на слова, сохраняя исходное написание
+func Words(s string) []Word {
+	tokens := strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	words := make([]Word, len(tokens))
+	for i, t := range tokens {
+		lower := strings.ToLower(t)
+		words[i] = Word{Surface: t, Stem: Stem(lower), Stop: IsStopWord(lower)}
+	}
+	return words
+}
+
+// Stem возвращает основу слова; для кириллицы используется стеммер Snowball
+func Stem(word string) string {
+	word = strings.ToLower(word)
+	for _, r := range word {
+		if unicode.Is(unicode.Cyrillic, r) {
+			return russian.Stem(word, true)
+		}
+	}
+	return word
+}
+
+// IsStopWord сообщает, что слово не несет смысла для ключевых слов
+func IsStopWord(word string) bool {
+	word = strings.ToLower(word)
+	if len([]rune(word)) < 3 || russian.IsStopWord(word) || newsStopWords[word] {
+		return true
+	}
+	for _, r := range word {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// newsStopWords дополняет стоп-слова Snowball частыми в новостях служебными словами
+var newsStopWords = map[string]bool{
+	"это": true, "этот": true, "эта": true, "эти": true, "этого": true, "этой": true, "этом": true,
+	"также": true, "который": true, "которая": true, "которое": true, "которые": true, "которых": true,
+	"заявил": true, "заявила": true, "заявили": true, "сообщил": true, "сообщила": true, "сообщили": true,
+	"сообщает": true, "сообщается": true, "отметил": true, "отметила": true, "рассказал": true,
+	"рассказала": true, "передает": true, "пишет": true, "словам": true, "данным": true,
+	"году": true, "года": true, "год": true, "лет": true, "время": true, "день": true, "дня": true,
+	"января": true, "февраля": true, "марта": true, "апреля": true, "мая": true, "июня": true,
+	"июля": true, "августа": true, "сентября": true, "октября": true, "ноября": true, "декабря": true,
+	"понедельник": true, "вторник": true, "среду": true, "четверг": true, "пятницу": true,
+	"субботу": true, "воскресенье": true, "сегодня": true, "вчера": true, "завтра": true,
+	"может": true, "могут": true, "будет": true, "будут": true, "более": true, "менее": true,
+	"около": true, "после": true, "через": true, "среди": true, "против": true, "однако": true,
+	"the": true, "and": true, "for": true, "with": true, "from": true,
+}
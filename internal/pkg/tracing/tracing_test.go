@@ -0,0 +1,180 @@
+package tracing_test
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/XSAM/otelsql"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"agregator/api/internal/pkg/tracing"
+	"agregator/api/internal/service/redis"
+)
+
+// Запрос к API создает серверный спан, а SQL-запрос и команда Redis внутри
+// обработчика — его дочерние спаны в том же трейсе
+func TestRequestSpanHasSQLAndRedisChildren(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := tracing.NewProvider(recorder)
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	sql.Register("tracing-test", fakeDriver{})
+	database, err := otelsql.Open("tracing-test", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+	cache := redis.New(fakeRedis(t), "", slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(otelgin.Middleware(tracing.ServiceName))
+	router.GET("/items", func(c *gin.Context) {
+		ctx := c.Request.Context()
+		var n int
+		if err := database.QueryRowContext(ctx, "SELECT 1").Scan(&n); err != nil {
+			t.Error(err)
+		}
+		var v any
+		if _, err := cache.GetJSON(ctx, "items", &v); err != nil {
+			t.Error(err)
+		}
+		c.Status(http.StatusNoContent)
+	})
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/items", nil))
+
+	if err := provider.ForceFlush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	var server sdktrace.ReadOnlySpan
+	for _, span := range recorder.Ended() {
+		if span.SpanKind() == trace.SpanKindServer {
+			server = span
+		}
+	}
+	if server == nil {
+		t.Fatal("no server span recorded")
+	}
+
+	var sqlChild, redisChild bool
+	for _, span := range recorder.Ended() {
+		if span.Parent().SpanID() != server.SpanContext().SpanID() {
+			continue
+		}
+		if span.SpanContext().TraceID() != server.SpanContext().TraceID() {
+			t.Errorf("span %q has trace %s, want %s", span.Name(), span.SpanContext().TraceID(), server.SpanContext().TraceID())
+		}
+		switch {
+		case strings.HasPrefix(span.Name(), "sql."):
+			sqlChild = true
+		case span.Name() == "redis.GET":
+			redisChild = true
+		}
+	}
+	if !sqlChild {
+		t.Error("no SQL child span of the request span")
+	}
+	if !redisChild {
+		t.Error("no Redis child span of the request span")
+	}
+}
+
+// fakeRedis отвечает на PING и GET (промах) по протоколу RESP
+func fakeRedis(t *testing.T) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serveRedis(conn)
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func serveRedis(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		// Команда приходит массивом: *N, затем N пар $len / значение
+		header, err := r.ReadString('\n')
+		if err != nil || !strings.HasPrefix(header, "*") {
+			return
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(header[1:]))
+		if err != nil {
+			return
+		}
+		args := make([]string, n)
+		for i := range args {
+			if _, err := r.ReadString('\n'); err != nil {
+				return
+			}
+			arg, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			args[i] = strings.TrimSpace(arg)
+		}
+		reply := "$-1\r\n"
+		if len(args) > 0 && strings.EqualFold(args[0], "PING") {
+			reply = "+PONG\r\n"
+		}
+		if _, err := conn.Write([]byte(reply)); err != nil {
+			return
+		}
+	}
+}
+
+// fakeDriver — драйвер SQL, который на любой запрос возвращает одну строку с 1
+type fakeDriver struct{}
+
+func (fakeDriver) Open(string) (driver.Conn, error) { return fakeConn{}, nil }
+
+type fakeConn struct{}
+
+func (fakeConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (fakeConn) Close() error                        { return nil }
+func (fakeConn) Begin() (driver.Tx, error)           { return nil, errors.New("not supported") }
+
+func (fakeConn) QueryContext(context.Context, string, []driver.NamedValue) (driver.Rows, error) {
+	return &fakeRows{}, nil
+}
+
+type fakeRows struct{ done bool }
+
+func (*fakeRows) Columns() []string { return []string{"n"} }
+func (*fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0] = int64(1)
+	return nil
+}
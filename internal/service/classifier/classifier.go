@@ -0,0 +1,35 @@
+// Package classifier содержит реализации interfaces.Classifier для рубрикации групп.
+package classifier
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"agregator/api/internal/interfaces"
+	"agregator/api/internal/pkg/config"
+)
+
+// New создает классификатор по ключевым словам. Правила берутся из CATEGORY_RULES (JSON)
+// или из файла CATEGORY_RULES_FILE, иначе используются DefaultRules.
+func New() (interfaces.Classifier, error) {
+	// Разбираем в новую map: json.Unmarshal в DefaultRules дополнил бы общие правила по умолчанию
+	var rules Rules
+	switch {
+	case os.Getenv("CATEGORY_RULES") != "":
+		if err := json.Unmarshal([]byte(os.Getenv("CATEGORY_RULES")), &rules); err != nil {
+			return nil, fmt.Errorf("parse CATEGORY_RULES: %w", err)
+		}
+	case os.Getenv("CATEGORY_RULES_FILE") != "":
+		data, err := os.ReadFile(os.Getenv("CATEGORY_RULES_FILE"))
+		if err != nil {
+			return nil, fmt.Errorf("read CATEGORY_RULES_FILE: %w", err)
+		}
+		if err := json.Unmarshal(data, &rules); err != nil {
+			return nil, fmt.Errorf("parse CATEGORY_RULES_FILE: %w", err)
+		}
+	default:
+		rules = DefaultRules
+	}
+	return NewKeywords(rules, config.Float("CATEGORY_TITLE_WEIGHT", 3), config.Float("CATEGORY_MIN_SCORE", 2)), nil
+}
@@ -0,0 +1,70 @@
+package classifier
+
+import (
+	"context"
+	"sort"
+
+	"agregator/api/internal/pkg/text"
+)
+
+// Rules — ключевые слова по рубрикам. Слова сравниваются по основам, поэтому форма не важна.
+type Rules map[string][]string
+
+// DefaultRules — правила для основных разделов сайта
+var DefaultRules = Rules{
+	"politics": {"президент", "правительство", "госдума", "депутат", "министр", "выборы", "партия", "парламент",
+		"сенатор", "кремль", "санкции", "дипломат", "переговоры", "премьер", "губернатор", "закон"},
+	"economy": {"экономика", "рубль", "доллар", "курс", "инфляция", "банк", "центробанк", "ставка", "бюджет",
+		"нефть", "газ", "биржа", "акции", "компания", "рынок", "налог", "ввп", "инвестиции"},
+	"sports": {"матч", "футбол", "хоккей", "теннис", "чемпионат", "турнир", "сборная", "олимпиада", "спортсмен",
+		"тренер", "клуб", "гол", "победа", "лига", "кубок", "соревнования"},
+	"society": {"школа", "образование", "здравоохранение", "больница", "врач", "пенсия", "жители", "семья",
+		"дети", "транспорт", "погода", "праздник", "культура", "полиция", "суд", "происшествие"},
+}
+
+// Keywords относит текст к рубрике с наибольшим числом совпавших основ.
+// Совпадения в заголовках весят titleWeight, в тексте — 1; ниже minScore рубрика не назначается.
+type Keywords struct {
+	stems       map[string][]string // Основа -> рубрики
+	titleWeight float64
+	minScore    float64
+}
+
+func NewKeywords(rules Rules, titleWeight, minScore float64) *Keywords {
+	k := &Keywords{stems: make(map[string][]string), titleWeight: titleWeight, minScore: minScore}
+	categories := make([]string, 0, len(rules))
+	for category := range rules {
+		categories = append(categories, category)
+	}
+	// Порядок рубрик фиксирован, чтобы при равенстве результат не зависел от обхода map
+	sort.Strings(categories)
+	for _, category := range categories {
+		for _, word := range rules[category] {
+			stem := text.Stem(word)
+			k.stems[stem] = append(k.stems[stem], category)
+		}
+	}
+	return k
+}
+
+func (k *Keywords) Classify(_ context.Context, title, body string) (string, error) {
+	scores := make(map[string]float64)
+	k.score(scores, title, k.titleWeight)
+	k.score(scores, body, 1)
+
+	best, bestScore := "", k.minScore
+	for category, score := range scores {
+		if score > bestScore || (score == bestScore && (best == "" || category < best)) {
+			best, bestScore = category, score
+		}
+	}
+	return best, nil
+}
+
+func (k *Keywords) score(scores map[string]float64, s string, weight float64) {
+	for _, w := range text.Words(s) {
+		for _, category := range k.stems[w.Stem] {
+			scores[category] += weight
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package classifier
+
+import (
+	"context"
+	"testing"
+)
+
+func TestKeywordsClassify(t *testing.T) {
+	rules := Rules{
+		"economy":  {"ставка", "центробанк", "инфляция"},
+		"politics": {"президент", "выборы"},
+		"sports":   {"матч", "сборная"},
+		// Общая основа у двух рубрик
+		"society": {"выборы"},
+	}
+	k := NewKeywords(rules, 3, 2)
+
+	tests := []struct {
+		name  string
+		title string
+		body  string
+		want  string
+	}{
+		{"title match outweighs body", "Центробанк поднял ставку", "Президент прокомментировал решение", "economy"},
+		{"body matches add up", "Новости дня", "Матч сборной завершился вничью, сборная вышла в финал", "sports"},
+		{"word forms match by stem", "", "Ставки, ставкам и ставкой", "economy"},
+		{"below min score", "", "Президент", ""},
+		{"min score is inclusive", "", "Президент и выборы", "politics"},
+		// politics и society набрали поровну — побеждает первая по алфавиту
+		{"tie is broken by name", "Выборы", "", "politics"},
+		{"no matches", "Погода", "Завтра солнечно", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := k.Classify(context.Background(), tt.title, tt.body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Classify(%q, %q) = %q, want %q", tt.title, tt.body, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewRules(t *testing.T) {
+	t.Setenv("CATEGORY_RULES", `{"science": ["телескоп"]}`)
+	c, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := c.Classify(context.Background(), "Новый телескоп", "")
+	if got != "science" {
+		t.Errorf("Classify() with CATEGORY_RULES = %q, want science", got)
+	}
+	if got, _ := c.Classify(context.Background(), "Центробанк снизил ставку", ""); got != "" {
+		t.Errorf("Classify() = %q; CATEGORY_RULES must replace the default rules", got)
+	}
+
+	t.Setenv("CATEGORY_RULES", `{"broken"`)
+	if _, err := New(); err == nil {
+		t.Error("New() with malformed CATEGORY_RULES returned no error")
+	}
+}
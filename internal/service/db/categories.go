@@ -0,0 +1,101 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+
+	model "agregator/api/internal/model/db"
+)
+
+// GetUnclassified возвращает тексты еще не рубрицированных групп новее since, начиная с самых старых.
+// Вызывается фоновой рубрикацией, поэтому не проходит через предохранитель API.
+func (g *DB) GetUnclassified(ctx context.Context, since time.Time, limit int, timeout time.Duration) (docs []GroupText, err error) {
+	ctx, finish := g.beginJob(ctx, timeout)
+	defer finish(&err)
+
+	tx, err := g.db.BeginTxx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	if err = setLocalTimeout(ctx, tx, timeout); err != nil {
+		return nil, err
+	}
+
+	err = tx.SelectContext(ctx, &docs, groupTextSelect+`
+        WHERE groups.id IN (
+            SELECT id
+            FROM groups
+            WHERE category_checked_at IS NULL
+              AND time >= $1
+            ORDER BY time, id
+            LIMIT $2
+        )
+        GROUP BY groups.id
+        ORDER BY groups.time, groups.id`, since, limit)
+	if err != nil {
+		g.logger.ErrorContext(ctx, "Error executing query", "error", err.Error())
+		return nil, err
+	}
+	return docs, tx.Commit()
+}
+
+// SetCategories записывает рубрики групп; пустая строка означает, что рубрика не определена
+func (g *DB) SetCategories(ctx context.Context, categories map[uint64]string, timeout time.Duration) (err error) {
+	ctx, finish := g.beginJob(ctx, timeout)
+	defer finish(&err)
+
+	ids := make([]int64, 0, len(categories))
+	values := make([]string, 0, len(categories))
+	for id, category := range categories {
+		ids = append(ids, int64(id))
+		values = append(values, category)
+	}
+	tx, err := g.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err = setLocalTimeout(ctx, tx, timeout); err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+        UPDATE groups
+        SET category = NULLIF(c.category, ''), category_checked_at = NOW()
+        FROM unnest($1::bigint[], $2::text[]) AS c (id, category)
+        WHERE groups.id = c.id`, pq.Array(ids), pq.Array(values))
+	if err != nil {
+		g.logger.ErrorContext(ctx, "Error executing query", "error", err.Error())
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetCategoryCounts возвращает число групп каждой рубрики за последний window
+func (g *DB) GetCategoryCounts(ctx context.Context, window time.Duration, filter ListFilter) (counts []model.CategoryCount, err error) {
+	ctx, finish, err := g.begin(ctx, g.timeouts.Read)
+	if err != nil {
+		return nil, err
+	}
+	defer finish(&err)
+
+	clause, args := filter.where([]interface{}{window.Seconds()})
+	req := `
+        SELECT groups.category, COUNT(*) AS groups
+        FROM groups
+        WHERE groups.category IS NOT NULL
+          AND groups.time >= NOW() - make_interval(secs => $1)` + clause + `
+        GROUP BY groups.category
+        ORDER BY groups DESC, groups.category`
+
+	err = g.db.SelectContext(ctx, &counts, req, args...)
+	if err != nil {
+		g.logger.ErrorContext(ctx, "Error executing query", "error", err.Error())
+		return nil, err
+	}
+	return counts, nil
+}
@@ -0,0 +1,39 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	model "agregator/api/internal/model/db"
+)
+
+// GetCoverage возвращает все публикации группы в порядке времени
+func (g *DB) GetCoverage(ctx context.Context, id uint64) (entries []model.CoverageEntry, err error) {
+	ctx, finish, err := g.begin(ctx, g.timeouts.Read)
+	if err != nil {
+		return nil, err
+	}
+	defer finish(&err)
+
+	var exists bool
+	err = g.db.GetContext(ctx, &exists, `SELECT EXISTS (SELECT 1 FROM groups WHERE id = $1)`, id)
+	if err != nil {
+		g.logger.ErrorContext(ctx, "Error executing query", "error", err.Error())
+		return nil, err
+	}
+	if !exists {
+		return nil, fmt.Errorf("group with ID %d: %w", id, ErrNotFound)
+	}
+
+	err = g.db.SelectContext(ctx, &entries, `
+        SELECT feed.id, feed.source_name, feed.title, feed.time
+        FROM compares
+        JOIN feed ON feed.id = compares.feed_id
+        WHERE compares.group_id = $1
+        ORDER BY feed.time, feed.id`, id)
+	if err != nil {
+		g.logger.ErrorContext(ctx, "Error executing query", "error", err.Error())
+		return nil, err
+	}
+	return entries, nil
+}
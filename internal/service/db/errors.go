@@ -0,0 +1,110 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+var (
+	// ErrNotFound возвращается, когда запрошенной записи нет
+	ErrNotFound = errors.New("not found")
+	// ErrInvalidInput возвращается при некорректных параметрах запроса
+	ErrInvalidInput = errors.New("invalid input")
+	// ErrUnavailable возвращается, когда база недоступна и запрос к ней не выполнялся
+	ErrUnavailable = errors.New("database unavailable")
+	// ErrTimeout возвращается, когда запрос не уложился в отведенное время
+	ErrTimeout = errors.New("database query timed out")
+)
+
+// pqQueryCanceled — код ошибки Postgres при отмене запроса (таймаут или отмена клиентом)
+const pqQueryCanceled = "57014"
+
+// begin проверяет предохранитель и ограничивает запрос таймаутом.
+// Возвращаемую функцию нужно вызвать через defer с указателем на именованную ошибку метода:
+// она освобождает контекст, сообщает результат предохранителю и помечает таймауты.
+func (g *DB) begin(ctx context.Context, timeout time.Duration) (context.Context, func(*error), error) {
+	if err := g.breaker.Allow(); err != nil {
+		return ctx, nil, fmt.Errorf("%w: %w", ErrUnavailable, err)
+	}
+
+	parent := ctx
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	return ctx, func(err *error) {
+		cancel()
+		switch {
+		case *err == nil:
+			g.breaker.Done(false)
+		case errors.Is(parent.Err(), context.Canceled):
+			// Клиент ушел — о состоянии базы это ничего не говорит
+			g.breaker.Release()
+		case isUnavailable(*err):
+			g.breaker.Done(true)
+		case isTimeout(*err):
+			// Медленный запрос — ошибка этого запроса, а не недоступность базы: иначе несколько
+			// тяжелых поисков размыкали бы предохранитель для всего API
+			*err = fmt.Errorf("%w: %w", ErrTimeout, *err)
+			g.breaker.Release()
+		default:
+			// База ответила, пусть и ошибкой
+			g.breaker.Done(false)
+		}
+	}, nil
+}
+
+// setLocalTimeout поднимает statement_timeout из строки подключения до timeout
+// на время транзакции — для фоновых задач, которым нужно больше, чем запросам API
+func setLocalTimeout(ctx context.Context, tx *sqlx.Tx, timeout time.Duration) error {
+	_, err := tx.ExecContext(ctx, `SET LOCAL statement_timeout = `+strconv.FormatInt(timeout.Milliseconds(), 10))
+	return err
+}
+
+// beginJob — begin для фоновых задач: предохранитель не проверяется и не получает
+// результат, чтобы долгий проход задачи не переводил запросы API в режим без БД
+func (g *DB) beginJob(ctx context.Context, timeout time.Duration) (context.Context, func(*error)) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	return ctx, func(err *error) {
+		cancel()
+		if *err != nil && isTimeout(*err) {
+			*err = fmt.Errorf("%w: %w", ErrTimeout, *err)
+		}
+	}
+}
+
+func isTimeout(err error) bool {
+	var pqErr *pq.Error
+	return errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &pqErr) && pqErr.Code == pqQueryCanceled)
+}
+
+// isUnavailable отделяет недоступность базы (нет соединения, база останавливается
+// или не принимает подключения) от ошибок отдельных запросов
+func isUnavailable(err error) bool {
+	// context.DeadlineExceeded тоже реализует net.Error, но это таймаут запроса
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		// 08 — ошибки соединения, 57P01–57P03 — остановка сервера, 53300 — нет свободных подключений
+		return pqErr.Code.Class() == "08" || pqErr.Code == "57P01" || pqErr.Code == "57P02" ||
+			pqErr.Code == "57P03" || pqErr.Code == "53300"
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, driver.ErrBadConn) || errors.Is(err, sql.ErrConnDone) ||
+		errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, syscall.ECONNREFUSED)
+}
+
+// BreakerState возвращает состояние предохранителя базы (closed, open, half-open)
+func (g *DB) BreakerState() string {
+	return g.breaker.State().String()
+}
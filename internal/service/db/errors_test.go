@@ -0,0 +1,102 @@
+package db
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"syscall"
+	"testing"
+	"time"
+
+	"agregator/api/internal/pkg/breaker"
+	"github.com/lib/pq"
+)
+
+func TestIsUnavailable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"connection failure", &pq.Error{Code: "08006"}, true},
+		{"admin shutdown", &pq.Error{Code: "57P01"}, true},
+		{"cannot connect now", &pq.Error{Code: "57P03"}, true},
+		{"too many connections", &pq.Error{Code: "53300"}, true},
+		{"network error", &net.OpError{Op: "dial", Err: errors.New("no route to host")}, true},
+		{"refused", fmt.Errorf("dial: %w", syscall.ECONNREFUSED), true},
+		{"bad conn", driver.ErrBadConn, true},
+		{"connection closed", io.ErrUnexpectedEOF, true},
+		{"query canceled", &pq.Error{Code: pqQueryCanceled}, false},
+		{"deadline", context.DeadlineExceeded, false},
+		{"syntax error", &pq.Error{Code: "42601"}, false},
+		{"unique violation", &pq.Error{Code: "23505"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isUnavailable(tt.err); got != tt.want {
+				t.Errorf("isUnavailable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBeginBreaker(t *testing.T) {
+	tests := []struct {
+		name        string
+		err         error
+		wantState   breaker.State
+		wantTimeout bool
+	}{
+		{"success", nil, breaker.Closed, false},
+		{"unavailable trips", &pq.Error{Code: "08006"}, breaker.Open, false},
+		// Медленные запросы не должны размыкать предохранитель
+		{"statement timeout", &pq.Error{Code: pqQueryCanceled}, breaker.Closed, true},
+		{"deadline", context.DeadlineExceeded, breaker.Closed, true},
+		{"query error", &pq.Error{Code: "42601"}, breaker.Closed, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := &DB{breaker: breaker.New(1, time.Hour)}
+			_, finish, err := g.begin(context.Background(), time.Second)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = tt.err
+			finish(&err)
+
+			if got := g.breaker.State(); got != tt.wantState {
+				t.Errorf("breaker state = %v, want %v", got, tt.wantState)
+			}
+			if got := errors.Is(err, ErrTimeout); got != tt.wantTimeout {
+				t.Errorf("errors.Is(%v, ErrTimeout) = %v, want %v", err, got, tt.wantTimeout)
+			}
+		})
+	}
+}
+
+func TestBeginOpen(t *testing.T) {
+	g := &DB{breaker: breaker.New(1, time.Hour)}
+	_, finish, _ := g.begin(context.Background(), time.Second)
+	err := error(&pq.Error{Code: "08006"})
+	finish(&err)
+
+	if _, _, err := g.begin(context.Background(), time.Second); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("begin() with an open breaker = %v, want ErrUnavailable", err)
+	}
+}
+
+func TestBeginClientCanceled(t *testing.T) {
+	g := &DB{breaker: breaker.New(1, time.Hour)}
+	ctx, cancel := context.WithCancel(context.Background())
+	_, finish, _ := g.begin(ctx, time.Second)
+	cancel()
+	err := error(io.ErrUnexpectedEOF)
+	finish(&err)
+
+	if got := g.breaker.State(); got != breaker.Closed {
+		t.Errorf("breaker state after client cancel = %v, want closed", got)
+	}
+}
@@ -0,0 +1,106 @@
+package db
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// ListFilter — общие условия для запросов списков. Группа проходит фильтр,
+// если среди ее публикаций (compares) есть источник из Sources и нет ни одного из ExcludeSources,
+// ее рубрика входит в Categories, а время попадает в [From, To) или в последние Window.
+type ListFilter struct {
+	Sources        []string
+	ExcludeSources []string
+	Categories     []string
+
+	From   time.Time
+	To     time.Time
+	Window time.Duration // Отсчитывается от текущего времени сервера БД
+}
+
+// bounded сообщает, задано ли ограничение по времени
+func (f ListFilter) bounded() bool {
+	return !f.From.IsZero() || !f.To.IsZero() || f.Window > 0
+}
+
+// Key возвращает стабильное представление фильтра для ключей кэша
+func (f ListFilter) Key() string {
+	var parts []string
+	if len(f.Sources) > 0 {
+		parts = append(parts, "src="+strings.Join(normalize(f.Sources), ","))
+	}
+	if len(f.ExcludeSources) > 0 {
+		parts = append(parts, "xsrc="+strings.Join(normalize(f.ExcludeSources), ","))
+	}
+	if len(f.Categories) > 0 {
+		parts = append(parts, "cat="+strings.Join(normalize(f.Categories), ","))
+	}
+	if !f.From.IsZero() {
+		parts = append(parts, "from="+f.From.UTC().Format(time.RFC3339))
+	}
+	if !f.To.IsZero() {
+		parts = append(parts, "to="+f.To.UTC().Format(time.RFC3339))
+	}
+	if f.Window > 0 {
+		parts = append(parts, "window="+f.Window.String())
+	}
+	return strings.Join(parts, ";")
+}
+
+// where дописывает к args значения фильтра и возвращает условия для WHERE (с ведущим AND)
+func (f ListFilter) where(args []interface{}) (string, []interface{}) {
+	var clause string
+	if len(f.Sources) > 0 {
+		args = append(args, pq.Array(f.Sources))
+		clause += `
+          AND EXISTS (
+              SELECT 1
+              FROM compares AS fc
+              JOIN feed AS ff ON ff.id = fc.feed_id
+              WHERE fc.group_id = groups.id
+                AND ff.source_name = ANY($` + strconv.Itoa(len(args)) + `)
+          )`
+	}
+	if len(f.ExcludeSources) > 0 {
+		args = append(args, pq.Array(f.ExcludeSources))
+		clause += `
+          AND NOT EXISTS (
+              SELECT 1
+              FROM compares AS fc
+              JOIN feed AS ff ON ff.id = fc.feed_id
+              WHERE fc.group_id = groups.id
+                AND ff.source_name = ANY($` + strconv.Itoa(len(args)) + `)
+          )`
+	}
+	if len(f.Categories) > 0 {
+		args = append(args, pq.Array(f.Categories))
+		clause += `
+          AND groups.category = ANY($` + strconv.Itoa(len(args)) + `)`
+	}
+	if !f.From.IsZero() {
+		args = append(args, f.From)
+		clause += `
+          AND groups.time >= $` + strconv.Itoa(len(args))
+	}
+	if !f.To.IsZero() {
+		args = append(args, f.To)
+		clause += `
+          AND groups.time < $` + strconv.Itoa(len(args))
+	}
+	if f.Window > 0 {
+		args = append(args, f.Window.Seconds())
+		clause += `
+          AND groups.time >= NOW() - make_interval(secs => $` + strconv.Itoa(len(args)) + `)`
+	}
+	return clause, args
+}
+
+func normalize(values []string) []string {
+	values = slices.Clone(values)
+	slices.Sort(values)
+	return slices.Compact(values)
+}
@@ -0,0 +1,314 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Миграции лежат в migrations/ в виде пар NNNN_name.up.sql / NNNN_name.down.sql
+// и вшиваются в бинарник. Текущая версия хранится в schema_migrations
+// (одна строка, как у golang-migrate).
+//
+// Файл, начинающийся со строки noTxMarker, выполняется вне транзакции по одной
+// инструкции — это нужно для CREATE INDEX CONCURRENTLY, который не блокирует запись,
+// и для DO-блоков, которые фиксируют большие обновления порциями (COMMIT в цикле).
+
+//go:embed migrations/*.sql
+var migrationsFS embed.FS
+
+// migrationLockID — ключ advisory-блокировки, чтобы миграции не запускались параллельно
+const migrationLockID = 7_390_112_034
+
+// noTxMarker — первая строка миграции, которую нужно выполнять вне транзакции
+const noTxMarker = "-- migrate:no-transaction"
+
+type migration struct {
+	version int64
+	name    string
+	up      script
+	down    script
+}
+
+// script — SQL одного направления миграции
+type script struct {
+	sql  string
+	noTx bool
+}
+
+func newScript(body string) script {
+	return script{sql: body, noTx: strings.HasPrefix(body, noTxMarker)}
+}
+
+func loadMigrations() ([]migration, error) {
+	files, err := fs.Glob(migrationsFS, "migrations/*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*migration)
+	for _, file := range files {
+		base := path.Base(file)
+		prefix, rest, ok := strings.Cut(base, "_")
+		if !ok {
+			return nil, fmt.Errorf("invalid migration file name %q", base)
+		}
+		version, err := strconv.ParseInt(prefix, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %q: %w", base, err)
+		}
+		body, err := migrationsFS.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &migration{version: version}
+			byVersion[version] = m
+		}
+		switch {
+		case strings.HasSuffix(rest, ".up.sql"):
+			m.name = strings.TrimSuffix(rest, ".up.sql")
+			m.up = newScript(string(body))
+		case strings.HasSuffix(rest, ".down.sql"):
+			m.down = newScript(string(body))
+		default:
+			return nil, fmt.Errorf("invalid migration direction in %q", base)
+		}
+	}
+
+	migrations := make([]migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.up.sql == "" || m.down.sql == "" {
+			return nil, fmt.Errorf("migration %d must have both up and down files", m.version)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].version < migrations[j].version })
+	return migrations, nil
+}
+
+// LatestSchemaVersion возвращает версию схемы, которую поддерживает этот бинарник
+func LatestSchemaVersion() int64 {
+	migrations, err := loadMigrations()
+	if err != nil || len(migrations) == 0 {
+		return 0
+	}
+	return migrations[len(migrations)-1].version
+}
+
+// CheckSchema проверяет, что база мигрирована ровно до поддерживаемой версии
+func (g *DB) CheckSchema(ctx context.Context) error {
+	version, dirty, err := g.SchemaVersion(ctx)
+	if err != nil {
+		return err
+	}
+	if dirty {
+		return fmt.Errorf("schema version %d is dirty, fix the database and rerun migrations", version)
+	}
+	if latest := LatestSchemaVersion(); version != latest {
+		return fmt.Errorf("unsupported schema version %d, expected %d (run \"api migrate up\")", version, latest)
+	}
+	return nil
+}
+
+// MigrateUp применяет все еще не примененные миграции, каждую в своей транзакции
+// (кроме помеченных noTxMarker)
+func (g *DB) MigrateUp(ctx context.Context) error {
+	return g.migrate(ctx, func(conn *sql.Conn, current int64, migrations []migration) error {
+		for _, m := range migrations {
+			if m.version <= current {
+				continue
+			}
+			g.logger.InfoContext(ctx, "Applying migration", "version", m.version, "name", m.name)
+			if err := applyMigration(ctx, conn, m.up, m.version); err != nil {
+				return fmt.Errorf("migration %d_%s: %w", m.version, m.name, err)
+			}
+		}
+		return nil
+	})
+}
+
+// MigrateDown откатывает steps последних примененных миграций
+func (g *DB) MigrateDown(ctx context.Context, steps int) error {
+	return g.migrate(ctx, func(conn *sql.Conn, current int64, migrations []migration) error {
+		for i := len(migrations) - 1; i >= 0 && steps > 0; i-- {
+			m := migrations[i]
+			if m.version > current {
+				continue
+			}
+			var previous int64
+			if i > 0 {
+				previous = migrations[i-1].version
+			}
+			g.logger.InfoContext(ctx, "Reverting migration", "version", m.version, "name", m.name)
+			if err := applyMigration(ctx, conn, m.down, previous); err != nil {
+				return fmt.Errorf("revert %d_%s: %w", m.version, m.name, err)
+			}
+			steps--
+		}
+		return nil
+	})
+}
+
+// migrate берет блокировку и текущую версию и передает их в fn
+func (g *DB) migrate(ctx context.Context, fn func(conn *sql.Conn, current int64, migrations []migration) error) error {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return err
+	}
+
+	conn, err := g.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, migrationLockID); err != nil {
+		return err
+	}
+	defer conn.ExecContext(context.WithoutCancel(ctx), `SELECT pg_advisory_unlock($1)`, migrationLockID)
+
+	if _, err := conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (version BIGINT NOT NULL PRIMARY KEY, dirty BOOLEAN NOT NULL)`); err != nil {
+		return err
+	}
+
+	version, dirty, err := g.SchemaVersion(ctx)
+	if err != nil {
+		return err
+	}
+	if dirty {
+		return fmt.Errorf("schema version %d is dirty", version)
+	}
+	return fn(conn, version, migrations)
+}
+
+// applyMigration выполняет SQL и записывает новую версию в одной транзакции
+func applyMigration(ctx context.Context, conn *sql.Conn, s script, version int64) error {
+	if s.noTx {
+		return applyNoTx(ctx, conn, s.sql, version)
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Построение индексов может идти дольше statement_timeout из строки подключения
+	if _, err := tx.ExecContext(ctx, `SET LOCAL statement_timeout = 0`); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, s.sql); err != nil {
+		return err
+	}
+	if err := setVersion(ctx, tx, version, false); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// applyNoTx выполняет инструкции по одной вне транзакции. Версия заранее помечается
+// как dirty: если миграция прервется посередине, следующий запуск остановится и
+// базу нужно будет поправить вручную (например, удалить INVALID-индекс).
+func applyNoTx(ctx context.Context, conn *sql.Conn, query string, version int64) error {
+	if err := setVersion(ctx, conn, version, true); err != nil {
+		return err
+	}
+	if _, err := conn.ExecContext(ctx, `SET statement_timeout = 0`); err != nil {
+		return err
+	}
+	defer conn.ExecContext(context.WithoutCancel(ctx), `RESET statement_timeout`)
+
+	for _, stmt := range splitStatements(query) {
+		if _, err := conn.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	return setVersion(ctx, conn, version, false)
+}
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// setVersion заменяет строку schema_migrations; версия 0 без dirty означает пустую схему
+func setVersion(ctx context.Context, db execer, version int64, dirty bool) error {
+	if _, err := db.ExecContext(ctx, `DELETE FROM schema_migrations`); err != nil {
+		return err
+	}
+	if version == 0 && !dirty {
+		return nil
+	}
+	_, err := db.ExecContext(ctx, `INSERT INTO schema_migrations (version, dirty) VALUES ($1, $2)`, version, dirty)
+	return err
+}
+
+// splitStatements делит SQL на инструкции по точке с запятой, отбрасывая комментарии.
+// Точки с запятой внутри строк, идентификаторов в кавычках и тел в долларовых кавычках
+// ($$ ... $$, $tag$ ... $tag$) инструкцию не завершают.
+func splitStatements(query string) []string {
+	var stmts []string
+	var cur strings.Builder
+	flush := func() {
+		if stmt := strings.TrimSpace(cur.String()); stmt != "" {
+			stmts = append(stmts, stmt)
+		}
+		cur.Reset()
+	}
+	for i := 0; i < len(query); {
+		switch c := query[i]; {
+		case c == '-' && strings.HasPrefix(query[i:], "--"):
+			end := strings.IndexByte(query[i:], '\n')
+			if end < 0 {
+				end = len(query) - i
+			}
+			i += end
+		case c == '\'' || c == '"':
+			end := len(query)
+			if n := strings.IndexByte(query[i+1:], c); n >= 0 {
+				end = i + n + 2
+			}
+			cur.WriteString(query[i:end])
+			i = end
+		case c == '$' && dollarTag(query[i:]) != "":
+			tag := dollarTag(query[i:])
+			end := len(query)
+			if n := strings.Index(query[i+len(tag):], tag); n >= 0 {
+				end = i + len(tag) + n + len(tag)
+			}
+			cur.WriteString(query[i:end])
+			i = end
+		case c == ';':
+			flush()
+			i++
+		default:
+			cur.WriteByte(c)
+			i++
+		}
+	}
+	flush()
+	return stmts
+}
+
+// dollarTag возвращает открывающую долларовую кавычку ($$ или $tag$) в начале s
+func dollarTag(s string) string {
+	for i := 1; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '$':
+			return s[:i+1]
+		case c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || i > 1 && c >= '0' && c <= '9':
+		default:
+			return ""
+		}
+	}
+	return ""
+}
@@ -0,0 +1,91 @@
+package db
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSplitStatements(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{"single", "SELECT 1", []string{"SELECT 1"}},
+		{"several", "SELECT 1;\nSELECT 2;\n\n", []string{"SELECT 1", "SELECT 2"}},
+		{"empty", " ;\n; ", nil},
+		{"comments are dropped", "-- header; with semicolon\nSELECT 1; -- trailing\nSELECT 2 -- no newline", []string{"SELECT 1", "SELECT 2"}},
+		{"semicolon in string", "INSERT INTO t VALUES ('a;b'); SELECT 1", []string{"INSERT INTO t VALUES ('a;b')", "SELECT 1"}},
+		{"escaped quote in string", "SELECT 'it''s; fine'; SELECT 2", []string{"SELECT 'it''s; fine'", "SELECT 2"}},
+		{"comment marker in string", "SELECT '--not a comment'; SELECT 2", []string{"SELECT '--not a comment'", "SELECT 2"}},
+		{"quoted identifier", `CREATE TABLE "a;b" (id int); SELECT 1`, []string{`CREATE TABLE "a;b" (id int)`, "SELECT 1"}},
+		{
+			"dollar-quoted body",
+			"DO $$\nBEGIN\n  PERFORM 1;\n  COMMIT;\nEND\n$$;\nSELECT 1",
+			[]string{"DO $$\nBEGIN\n  PERFORM 1;\n  COMMIT;\nEND\n$$", "SELECT 1"},
+		},
+		{
+			"tagged dollar quotes nest $$",
+			"CREATE FUNCTION f() RETURNS text AS $fn$ SELECT $$a;b$$; $fn$ LANGUAGE sql; SELECT 1",
+			[]string{"CREATE FUNCTION f() RETURNS text AS $fn$ SELECT $$a;b$$; $fn$ LANGUAGE sql", "SELECT 1"},
+		},
+		{"positional parameters", "SELECT $1, $2; SELECT 3", []string{"SELECT $1, $2", "SELECT 3"}},
+		{"unterminated string", "SELECT 'a;b", []string{"SELECT 'a;b"}},
+		{"unterminated dollar quote", "DO $$ BEGIN; END", []string{"DO $$ BEGIN; END"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitStatements(tt.query); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitStatements(%q) = %q, want %q", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewScript(t *testing.T) {
+	tests := []struct {
+		body string
+		noTx bool
+	}{
+		{noTxMarker + "\nCREATE INDEX CONCURRENTLY i ON t (a);", true},
+		{"CREATE INDEX i ON t (a);", false},
+		// Маркер действует только в первой строке
+		{"SELECT 1;\n" + noTxMarker + "\n", false},
+	}
+	for _, tt := range tests {
+		if got := newScript(tt.body).noTx; got != tt.noTx {
+			t.Errorf("newScript(%q).noTx = %v, want %v", tt.body, got, tt.noTx)
+		}
+	}
+}
+
+func TestLoadMigrations(t *testing.T) {
+	migrations, err := loadMigrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) == 0 {
+		t.Fatal("no migrations embedded")
+	}
+	for i, m := range migrations {
+		if m.version != int64(i+1) {
+			t.Errorf("migration %d has version %d; versions must be consecutive", i, m.version)
+		}
+		if m.name == "" {
+			t.Errorf("migration %d has no name", m.version)
+		}
+		for dir, s := range map[string]script{"up": m.up, "down": m.down} {
+			// CREATE/DROP INDEX CONCURRENTLY нельзя выполнить внутри транзакции
+			if strings.Contains(s.sql, "CONCURRENTLY") && !s.noTx {
+				t.Errorf("migration %d %s uses CONCURRENTLY but is not marked %q", m.version, dir, noTxMarker)
+			}
+			if len(splitStatements(s.sql)) == 0 {
+				t.Errorf("migration %d %s has no statements", m.version, dir)
+			}
+		}
+	}
+	if got := LatestSchemaVersion(); got != migrations[len(migrations)-1].version {
+		t.Errorf("LatestSchemaVersion() = %d, want %d", got, migrations[len(migrations)-1].version)
+	}
+}
@@ -0,0 +1,9 @@
+-- migrate:no-transaction
+-- Откатываются только индексы. Таблицы и колонки up лишь подхватывает с IF NOT EXISTS:
+-- они могут принадлежать агрегатору и хранить его данные (в том числе views и embedding).
+
+DROP INDEX CONCURRENTLY IF EXISTS groups_embedding_hnsw_idx;
+DROP INDEX CONCURRENTLY IF EXISTS compares_feed_id_idx;
+DROP INDEX CONCURRENTLY IF EXISTS compares_group_id_idx;
+DROP INDEX CONCURRENTLY IF EXISTS groups_is_rt_time_idx;
+DROP INDEX CONCURRENTLY IF EXISTS groups_time_idx;
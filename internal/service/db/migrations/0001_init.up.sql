@@ -0,0 +1,47 @@
+-- migrate:no-transaction
+-- Базовая схема, от которой зависит API. Таблицы могли быть созданы раньше
+-- сервисом-агрегатором, поэтому все объекты создаются с IF NOT EXISTS.
+-- Индексы строятся CONCURRENTLY, чтобы не блокировать запись агрегатора,
+-- поэтому миграция выполняется вне транзакции.
+
+CREATE EXTENSION IF NOT EXISTS vector;
+
+CREATE TABLE IF NOT EXISTS feed (
+    id          BIGSERIAL PRIMARY KEY,
+    title       TEXT        NOT NULL,
+    description TEXT,
+    full_text   TEXT,
+    link        TEXT        NOT NULL,
+    source_name TEXT        NOT NULL,
+    time        TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    enclosure   TEXT
+);
+
+CREATE TABLE IF NOT EXISTS groups (
+    id          BIGSERIAL PRIMARY KEY,
+    title       TEXT        NOT NULL,
+    description TEXT,
+    full_text   TEXT,
+    time        TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    feed_id     BIGINT      NOT NULL REFERENCES feed (id),
+    is_rt       BOOLEAN     NOT NULL DEFAULT FALSE,
+    views       BIGINT      NOT NULL DEFAULT 0,
+    -- Размерность должна совпадать с моделью, которой агрегатор считает эмбеддинги
+    embedding   vector(768)
+);
+
+CREATE TABLE IF NOT EXISTS compares (
+    group_id BIGINT NOT NULL REFERENCES groups (id) ON DELETE CASCADE,
+    feed_id  BIGINT NOT NULL REFERENCES feed (id) ON DELETE CASCADE
+);
+
+-- Колонки, которых может не быть в ранее созданных таблицах
+ALTER TABLE groups ADD COLUMN IF NOT EXISTS is_rt BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE groups ADD COLUMN IF NOT EXISTS views BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE groups ADD COLUMN IF NOT EXISTS embedding vector(768);
+
+CREATE INDEX CONCURRENTLY IF NOT EXISTS groups_time_idx ON groups (time DESC);
+CREATE INDEX CONCURRENTLY IF NOT EXISTS groups_is_rt_time_idx ON groups (is_rt, time DESC);
+CREATE INDEX CONCURRENTLY IF NOT EXISTS compares_group_id_idx ON compares (group_id);
+CREATE INDEX CONCURRENTLY IF NOT EXISTS compares_feed_id_idx ON compares (feed_id);
+CREATE INDEX CONCURRENTLY IF NOT EXISTS groups_embedding_hnsw_idx ON groups USING hnsw (embedding vector_cosine_ops);
@@ -0,0 +1,10 @@
+DROP TRIGGER IF EXISTS feed_refresh_cover ON feed;
+DROP TRIGGER IF EXISTS compares_refresh_cover ON compares;
+DROP TRIGGER IF EXISTS groups_set_cover ON groups;
+
+DROP FUNCTION IF EXISTS feed_refresh_cover();
+DROP FUNCTION IF EXISTS compares_refresh_cover();
+DROP FUNCTION IF EXISTS groups_set_cover();
+DROP FUNCTION IF EXISTS pick_group_cover(BIGINT, BIGINT);
+
+ALTER TABLE groups DROP COLUMN IF EXISTS cover;
@@ -0,0 +1,65 @@
+-- Обложка группы выбирается детерминированно и хранится в groups.cover:
+-- сначала основной источник (groups.feed_id), затем самая свежая публикация,
+-- при равенстве времени — меньший feed.id. Триггеры пересчитывают обложку
+-- при изменении состава группы, основного источника или картинки в ленте.
+
+ALTER TABLE groups ADD COLUMN IF NOT EXISTS cover TEXT;
+
+CREATE OR REPLACE FUNCTION pick_group_cover(p_group_id BIGINT, p_feed_id BIGINT) RETURNS TEXT
+LANGUAGE sql STABLE AS $$
+    SELECT f.enclosure
+    FROM feed AS f
+    WHERE (f.id = p_feed_id OR f.id IN (SELECT c.feed_id FROM compares AS c WHERE c.group_id = p_group_id))
+      AND f.enclosure IS NOT NULL
+      AND f.enclosure <> ''
+    ORDER BY (f.id = p_feed_id) DESC, f.time DESC, f.id
+    LIMIT 1
+$$;
+
+CREATE OR REPLACE FUNCTION groups_set_cover() RETURNS TRIGGER
+LANGUAGE plpgsql AS $$
+BEGIN
+    NEW.cover := pick_group_cover(NEW.id, NEW.feed_id);
+    RETURN NEW;
+END
+$$;
+
+CREATE OR REPLACE FUNCTION compares_refresh_cover() RETURNS TRIGGER
+LANGUAGE plpgsql AS $$
+BEGIN
+    IF TG_OP IN ('INSERT', 'UPDATE') THEN
+        UPDATE groups SET cover = pick_group_cover(id, feed_id) WHERE id = NEW.group_id;
+    END IF;
+    IF TG_OP IN ('DELETE', 'UPDATE') THEN
+        UPDATE groups SET cover = pick_group_cover(id, feed_id) WHERE id = OLD.group_id;
+    END IF;
+    RETURN NULL;
+END
+$$;
+
+CREATE OR REPLACE FUNCTION feed_refresh_cover() RETURNS TRIGGER
+LANGUAGE plpgsql AS $$
+BEGIN
+    UPDATE groups SET cover = pick_group_cover(id, feed_id)
+    WHERE feed_id = NEW.id
+       OR id IN (SELECT c.group_id FROM compares AS c WHERE c.feed_id = NEW.id);
+    RETURN NULL;
+END
+$$;
+
+DROP TRIGGER IF EXISTS groups_set_cover ON groups;
+CREATE TRIGGER groups_set_cover
+    BEFORE INSERT OR UPDATE OF feed_id ON groups
+    FOR EACH ROW EXECUTE FUNCTION groups_set_cover();
+
+DROP TRIGGER IF EXISTS compares_refresh_cover ON compares;
+CREATE TRIGGER compares_refresh_cover
+    AFTER INSERT OR UPDATE OR DELETE ON compares
+    FOR EACH ROW EXECUTE FUNCTION compares_refresh_cover();
+
+DROP TRIGGER IF EXISTS feed_refresh_cover ON feed;
+CREATE TRIGGER feed_refresh_cover
+    AFTER UPDATE OF enclosure, time ON feed
+    FOR EACH ROW EXECUTE FUNCTION feed_refresh_cover();
+
+UPDATE groups SET cover = pick_group_cover(id, feed_id);
@@ -0,0 +1,35 @@
+-- migrate:no-transaction
+-- Выполняется вне транзакции, как и up: обложки пересчитываются порциями.
+DROP TRIGGER IF EXISTS sources_refresh_cover ON sources;
+DROP FUNCTION IF EXISTS sources_refresh_cover();
+
+CREATE OR REPLACE FUNCTION pick_group_cover(p_group_id BIGINT, p_feed_id BIGINT) RETURNS TEXT
+LANGUAGE sql STABLE AS $$
+    SELECT f.enclosure
+    FROM feed AS f
+    WHERE (f.id = p_feed_id OR f.id IN (SELECT c.feed_id FROM compares AS c WHERE c.group_id = p_group_id))
+      AND f.enclosure IS NOT NULL
+      AND f.enclosure <> ''
+    ORDER BY (f.id = p_feed_id) DESC, f.time DESC, f.id
+    LIMIT 1
+$$;
+
+DO $$
+DECLARE
+    last_id BIGINT := 0;
+    max_id  BIGINT;
+BEGIN
+    SELECT COALESCE(MAX(id), 0) INTO max_id FROM groups;
+    WHILE last_id < max_id LOOP
+        UPDATE groups SET cover = pick_group_cover(id, feed_id)
+        WHERE id > last_id AND id <= last_id + 5000;
+        last_id := last_id + 5000;
+        COMMIT;
+    END LOOP;
+END
+$$;
+
+DROP TRIGGER IF EXISTS feed_register_source ON feed;
+DROP FUNCTION IF EXISTS feed_register_source();
+DROP INDEX CONCURRENTLY IF EXISTS feed_source_name_idx;
+DROP TABLE IF EXISTS sources;
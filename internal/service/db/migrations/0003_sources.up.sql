@@ -0,0 +1,90 @@
+-- migrate:no-transaction
+-- Реестр источников. Ключ — feed.source_name; новые имена из ленты
+-- регистрируются триггером с названием по умолчанию, метаданные заполняет редакция.
+-- Миграция выполняется вне транзакции: индекс по feed строится CONCURRENTLY,
+-- а обложки пересчитываются порциями, чтобы не блокировать запись агрегатора.
+
+CREATE TABLE IF NOT EXISTS sources (
+    name         TEXT PRIMARY KEY,
+    display_name TEXT     NOT NULL,
+    logo_url     TEXT,
+    site_url     TEXT,
+    region       TEXT,
+    language     TEXT,
+    priority     INTEGER  NOT NULL DEFAULT 0,
+    trust_level  SMALLINT NOT NULL DEFAULT 0
+);
+
+INSERT INTO sources (name, display_name)
+SELECT DISTINCT source_name, source_name FROM feed
+ON CONFLICT (name) DO NOTHING;
+
+CREATE INDEX CONCURRENTLY IF NOT EXISTS feed_source_name_idx ON feed (source_name);
+
+CREATE OR REPLACE FUNCTION feed_register_source() RETURNS TRIGGER
+LANGUAGE plpgsql AS $$
+BEGIN
+    INSERT INTO sources (name, display_name) VALUES (NEW.source_name, NEW.source_name)
+    ON CONFLICT (name) DO NOTHING;
+    RETURN NEW;
+END
+$$;
+
+DROP TRIGGER IF EXISTS feed_register_source ON feed;
+CREATE TRIGGER feed_register_source
+    BEFORE INSERT OR UPDATE OF source_name ON feed
+    FOR EACH ROW EXECUTE FUNCTION feed_register_source();
+
+-- Обложка: основной источник, затем приоритет источника, затем свежесть
+CREATE OR REPLACE FUNCTION pick_group_cover(p_group_id BIGINT, p_feed_id BIGINT) RETURNS TEXT
+LANGUAGE sql STABLE AS $$
+    SELECT f.enclosure
+    FROM feed AS f
+    LEFT JOIN sources AS s ON s.name = f.source_name
+    WHERE (f.id = p_feed_id OR f.id IN (SELECT c.feed_id FROM compares AS c WHERE c.group_id = p_group_id))
+      AND f.enclosure IS NOT NULL
+      AND f.enclosure <> ''
+    ORDER BY (f.id = p_feed_id) DESC, COALESCE(s.priority, 0) DESC, f.time DESC, f.id
+    LIMIT 1
+$$;
+
+-- Смена приоритета источника меняет выбор обложки у всех групп, где он участвует.
+-- Для крупного источника это затрагивает много строк, поэтому приоритеты лучше
+-- менять вне часов пиковой записи агрегатора.
+CREATE OR REPLACE FUNCTION sources_refresh_cover() RETURNS TRIGGER
+LANGUAGE plpgsql AS $$
+BEGIN
+    UPDATE groups SET cover = pick_group_cover(id, feed_id)
+    WHERE feed_id IN (SELECT f.id FROM feed AS f WHERE f.source_name = NEW.name)
+       OR id IN (
+           SELECT c.group_id
+           FROM compares AS c
+           JOIN feed AS f ON f.id = c.feed_id
+           WHERE f.source_name = NEW.name
+       );
+    RETURN NULL;
+END
+$$;
+
+DROP TRIGGER IF EXISTS sources_refresh_cover ON sources;
+CREATE TRIGGER sources_refresh_cover
+    AFTER UPDATE OF priority ON sources
+    FOR EACH ROW
+    WHEN (OLD.priority IS DISTINCT FROM NEW.priority)
+    EXECUTE FUNCTION sources_refresh_cover();
+
+-- Пересчет обложек существующих групп порциями по идентификатору, с фиксацией каждой порции
+DO $$
+DECLARE
+    last_id BIGINT := 0;
+    max_id  BIGINT;
+BEGIN
+    SELECT COALESCE(MAX(id), 0) INTO max_id FROM groups;
+    WHILE last_id < max_id LOOP
+        UPDATE groups SET cover = pick_group_cover(id, feed_id)
+        WHERE id > last_id AND id <= last_id + 5000;
+        last_id := last_id + 5000;
+        COMMIT;
+    END LOOP;
+END
+$$;
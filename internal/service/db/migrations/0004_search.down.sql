@@ -0,0 +1,3 @@
+-- migrate:no-transaction
+DROP INDEX CONCURRENTLY IF EXISTS groups_feed_id_idx;
+DROP INDEX CONCURRENTLY IF EXISTS feed_fts_idx;
@@ -0,0 +1,8 @@
+-- migrate:no-transaction
+-- Полнотекстовый индекс для гибридного поиска. Ищем по тому же тексту, что показывается
+-- в списке, — заголовку и описанию основного источника группы (groups.title может быть пуст).
+-- Выражение должно совпадать с feedTSVector в search.go; groups_feed_id_idx нужен, чтобы
+-- от найденных публикаций перейти к группам.
+CREATE INDEX CONCURRENTLY IF NOT EXISTS feed_fts_idx ON feed
+    USING gin (to_tsvector('russian', title || ' ' || COALESCE(description, '')));
+CREATE INDEX CONCURRENTLY IF NOT EXISTS groups_feed_id_idx ON groups (feed_id);
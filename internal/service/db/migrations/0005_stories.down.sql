@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS stories_updated_at_idx;
+DROP INDEX IF EXISTS groups_story_unchecked_idx;
+DROP INDEX IF EXISTS groups_story_id_time_idx;
+ALTER TABLE groups DROP COLUMN IF EXISTS story_checked_at;
+ALTER TABLE groups DROP COLUMN IF EXISTS story_id;
+DROP TABLE IF EXISTS stories;
@@ -0,0 +1,21 @@
+-- migrate:no-transaction
+-- Сюжеты: цепочки групп, связанных близостью эмбеддингов и времени.
+-- Заполняются фоновым связывателем (DB.LinkStories).
+-- Индексы строятся CONCURRENTLY, чтобы не блокировать запись агрегатора в groups,
+-- поэтому миграция выполняется вне транзакции.
+
+CREATE TABLE IF NOT EXISTS stories (
+    id           BIGSERIAL PRIMARY KEY,
+    title        TEXT        NOT NULL, -- Заголовок первой группы сюжета
+    started_at   TIMESTAMPTZ NOT NULL,
+    updated_at   TIMESTAMPTZ NOT NULL,
+    groups_count INTEGER     NOT NULL DEFAULT 0
+);
+
+ALTER TABLE groups ADD COLUMN IF NOT EXISTS story_id BIGINT REFERENCES stories (id) ON DELETE SET NULL;
+-- Когда связыватель обработал группу; NULL — группа еще не рассматривалась
+ALTER TABLE groups ADD COLUMN IF NOT EXISTS story_checked_at TIMESTAMPTZ;
+
+CREATE INDEX CONCURRENTLY IF NOT EXISTS groups_story_id_time_idx ON groups (story_id, time) WHERE story_id IS NOT NULL;
+CREATE INDEX CONCURRENTLY IF NOT EXISTS groups_story_unchecked_idx ON groups (time) WHERE story_checked_at IS NULL;
+CREATE INDEX CONCURRENTLY IF NOT EXISTS stories_updated_at_idx ON stories (updated_at DESC);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS tags_tag_idx;
+DROP TABLE IF EXISTS tags;
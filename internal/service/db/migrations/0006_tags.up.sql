@@ -0,0 +1,14 @@
+-- migrate:no-transaction
+-- Ключевые слова групп. Заполняются офлайн-задачей "api tags".
+-- Индекс строится CONCURRENTLY, как и в остальных миграциях, поэтому миграция
+-- выполняется вне транзакции.
+
+CREATE TABLE IF NOT EXISTS tags (
+    group_id BIGINT           NOT NULL REFERENCES groups (id) ON DELETE CASCADE,
+    tag      TEXT             NOT NULL, -- Основы слов через пробел
+    label    TEXT             NOT NULL, -- Написание для показа
+    score    DOUBLE PRECISION NOT NULL,
+    PRIMARY KEY (group_id, tag)
+);
+
+CREATE INDEX CONCURRENTLY IF NOT EXISTS tags_tag_idx ON tags (tag);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS groups_category_unchecked_idx;
+DROP INDEX IF EXISTS groups_category_time_idx;
+ALTER TABLE groups DROP COLUMN IF EXISTS category_checked_at;
+ALTER TABLE groups DROP COLUMN IF EXISTS category;
@@ -0,0 +1,11 @@
+-- migrate:no-transaction
+-- Рубрика группы. Заполняется классификатором API; NULL при заполненном
+-- category_checked_at означает, что рубрика не определена.
+-- Индексы строятся CONCURRENTLY, чтобы не блокировать запись агрегатора в groups,
+-- поэтому миграция выполняется вне транзакции.
+
+ALTER TABLE groups ADD COLUMN IF NOT EXISTS category TEXT;
+ALTER TABLE groups ADD COLUMN IF NOT EXISTS category_checked_at TIMESTAMPTZ;
+
+CREATE INDEX CONCURRENTLY IF NOT EXISTS groups_category_time_idx ON groups (category, time DESC) WHERE category IS NOT NULL;
+CREATE INDEX CONCURRENTLY IF NOT EXISTS groups_category_unchecked_idx ON groups (time) WHERE category_checked_at IS NULL;
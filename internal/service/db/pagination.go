@@ -0,0 +1,106 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	model "agregator/api/internal/model/db"
+)
+
+// SourceCursor — позиция в списке источников группы, упорядоченном по (time DESC, id)
+type SourceCursor struct {
+	Time time.Time
+	ID   uint64
+}
+
+// Encode возвращает непрозрачную строку курсора для клиента
+func (c SourceCursor) Encode() string {
+	raw := strconv.FormatInt(c.Time.UnixMicro(), 10) + ":" + strconv.FormatUint(c.ID, 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// ParseSourceCursor разбирает строку, полученную из Encode
+func ParseSourceCursor(s string) (SourceCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return SourceCursor{}, fmt.Errorf("%w: malformed cursor", ErrInvalidInput)
+	}
+	ts, id, ok := strings.Cut(string(raw), ":")
+	micro, err1 := strconv.ParseInt(ts, 10, 64)
+	feedID, err2 := strconv.ParseUint(id, 10, 64)
+	if !ok || err1 != nil || err2 != nil {
+		return SourceCursor{}, fmt.Errorf("%w: malformed cursor", ErrInvalidInput)
+	}
+	return SourceCursor{Time: time.UnixMicro(micro), ID: feedID}, nil
+}
+
+// GetGroupSources возвращает страницу источников группы после cursor (nil — с начала)
+func (g *DB) GetGroupSources(ctx context.Context, id uint64, cursor *SourceCursor, limit uint64, withText bool) (page model.SourcePage, err error) {
+	ctx, finish, err := g.begin(ctx, g.timeouts.Read)
+	if err != nil {
+		return model.SourcePage{}, err
+	}
+	defer finish(&err)
+
+	err = g.db.GetContext(ctx, &page.Total, `
+        SELECT COUNT(cp.feed_id)
+        FROM groups AS g
+        LEFT JOIN compares AS cp ON cp.group_id = g.id
+        WHERE g.id = $1
+        GROUP BY g.id`, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.SourcePage{}, fmt.Errorf("group with ID %d: %w", id, ErrNotFound)
+		}
+		g.logger.ErrorContext(ctx, "Error executing query", "error", err.Error())
+		return model.SourcePage{}, err
+	}
+
+	// Берем на одну запись больше, чтобы понять, есть ли следующая страница
+	args := []interface{}{id, limit + 1}
+	var keyset string
+	if cursor != nil {
+		args = append(args, cursor.Time, cursor.ID)
+		keyset = `
+          AND (fc.time < $3 OR (fc.time = $3 AND fc.id > $4))`
+	}
+	req := `
+        SELECT ` + strings.ReplaceAll(sourceJSON, "{source_text}", sourceText(withText)) + ` AS source
+        FROM compares AS cp
+        JOIN groups AS g ON g.id = cp.group_id
+        JOIN feed AS fc ON fc.id = cp.feed_id
+        LEFT JOIN sources AS s ON s.name = fc.source_name
+        WHERE cp.group_id = $1` + keyset + `
+        ORDER BY fc.time DESC, fc.id
+        LIMIT $2`
+
+	var rows []json.RawMessage
+	err = g.db.SelectContext(ctx, &rows, req, args...)
+	if err != nil {
+		g.logger.ErrorContext(ctx, "Error executing query", "error", err.Error())
+		return model.SourcePage{}, err
+	}
+
+	page.Items = make([]model.Source, 0, len(rows))
+	for _, row := range rows {
+		var source model.Source
+		if err = json.Unmarshal(row, &source); err != nil {
+			g.logger.ErrorContext(ctx, "Error parsing source", "error", err.Error(), "id", id)
+			return model.SourcePage{}, err
+		}
+		page.Items = append(page.Items, source)
+	}
+	if uint64(len(page.Items)) > limit {
+		page.Items = page.Items[:limit]
+		last := page.Items[len(page.Items)-1]
+		page.NextCursor = SourceCursor{Time: last.Time, ID: last.ID}.Encode()
+	}
+	return page, nil
+}
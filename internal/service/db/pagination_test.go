@@ -0,0 +1,66 @@
+package db
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSourceCursorRoundTrip(t *testing.T) {
+	tests := []SourceCursor{
+		{Time: time.Date(2026, 10, 1, 9, 15, 30, 123456000, time.UTC), ID: 42},
+		{Time: time.Date(1969, 12, 31, 23, 59, 59, 0, time.UTC), ID: 1},
+		{Time: time.Unix(0, 0), ID: 18446744073709551615},
+	}
+	for _, want := range tests {
+		t.Run(want.Encode(), func(t *testing.T) {
+			got, err := ParseSourceCursor(want.Encode())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !got.Time.Equal(want.Time) || got.ID != want.ID {
+				t.Errorf("ParseSourceCursor(Encode()) = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestSourceCursorTruncatesToMicroseconds(t *testing.T) {
+	// Postgres хранит timestamptz с точностью до микросекунд, курсор тоже
+	c := SourceCursor{Time: time.Date(2026, 10, 1, 0, 0, 0, 1999, time.UTC), ID: 1}
+	got, err := ParseSourceCursor(c.Encode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := c.Time.Truncate(time.Microsecond); !got.Time.Equal(want) {
+		t.Errorf("Time = %v, want %v", got.Time, want)
+	}
+}
+
+func TestParseSourceCursorMalformed(t *testing.T) {
+	encode := func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) }
+	tests := []struct {
+		name   string
+		cursor string
+	}{
+		{"empty", ""},
+		{"not base64", "!!!"},
+		{"padded base64", base64.URLEncoding.EncodeToString([]byte("1:10"))},
+		{"no separator", encode("1700000000000000")},
+		{"missing id", encode("1700000000000000:")},
+		{"missing time", encode(":42")},
+		{"non-numeric time", encode("yesterday:42")},
+		{"negative id", encode("1700000000000000:-1")},
+		{"id overflow", encode("1700000000000000:18446744073709551616")},
+		{"extra part", encode("1700000000000000:42:1")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseSourceCursor(tt.cursor)
+			if !errors.Is(err, ErrInvalidInput) {
+				t.Errorf("ParseSourceCursor(%q) error = %v, want ErrInvalidInput", tt.cursor, err)
+			}
+		})
+	}
+}
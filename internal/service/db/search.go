@@ -0,0 +1,57 @@
+package db
+
+import (
+	"context"
+
+	model "agregator/api/internal/model/db"
+)
+
+// feedTSVector — текст, который показывается в списке (заголовок и описание основного
+// источника feed); совпадает с выражением индекса feed_fts_idx
+const feedTSVector = `to_tsvector('russian', feed.title || ' ' || COALESCE(feed.description, ''))`
+
+// SearchSemantic возвращает группы, ближайшие к вектору запроса; score — косинусное сходство
+func (g *DB) SearchSemantic(ctx context.Context, vec model.Vector, limit uint64, filter ListFilter) (groups []model.List, err error) {
+	ctx, finish, err := g.begin(ctx, g.timeouts.Similar)
+	if err != nil {
+		return nil, err
+	}
+	defer finish(&err)
+
+	clause, args := filter.where([]interface{}{vec, limit})
+	req := listColumns + `,
+            1 - (groups.embedding <=> $1::vector) AS score` + listFrom + `
+        WHERE groups.embedding IS NOT NULL` + clause + `
+        ORDER BY groups.embedding <=> $1::vector, groups.time DESC
+        LIMIT $2`
+
+	err = g.db.SelectContext(ctx, &groups, req, args...)
+	if err != nil {
+		g.logger.ErrorContext(ctx, "Error executing query", "error", err.Error())
+		return nil, err
+	}
+	return groups, nil
+}
+
+// SearchText возвращает группы по полнотекстовому поиску; score — ts_rank
+func (g *DB) SearchText(ctx context.Context, query string, limit uint64, filter ListFilter) (groups []model.List, err error) {
+	ctx, finish, err := g.begin(ctx, g.timeouts.Search)
+	if err != nil {
+		return nil, err
+	}
+	defer finish(&err)
+
+	clause, args := filter.where([]interface{}{query, limit})
+	req := listColumns + `,
+            ts_rank(` + feedTSVector + `, websearch_to_tsquery('russian', $1))::float8 AS score` + listFrom + `
+        WHERE ` + feedTSVector + ` @@ websearch_to_tsquery('russian', $1)` + clause + `
+        ORDER BY score DESC, groups.time DESC
+        LIMIT $2`
+
+	err = g.db.SelectContext(ctx, &groups, req, args...)
+	if err != nil {
+		g.logger.ErrorContext(ctx, "Error executing query", "error", err.Error())
+		return nil, err
+	}
+	return groups, nil
+}
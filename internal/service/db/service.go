@@ -1,79 +1,148 @@
 package db
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/XSAM/otelsql"
 	"github.com/jmoiron/sqlx"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
 
 	"agregator/api/internal/interfaces"
 	model "agregator/api/internal/model/db"
+	"agregator/api/internal/pkg/breaker"
+	"agregator/api/internal/pkg/config"
 )
 
 type DB struct {
-	db     *sqlx.DB
-	logger interfaces.Logger
+	db       *sqlx.DB
+	logger   interfaces.Logger
+	breaker  *breaker.Breaker // Размыкается при серии ошибок, чтобы не нагружать упавшую базу
+	timeouts Timeouts
+}
+
+// Timeouts — ограничения времени выполнения по типам операций
+type Timeouts struct {
+	Read    time.Duration // Выборки списков и отдельных групп
+	Search  time.Duration // Поиск по ILIKE
+	Similar time.Duration // Поиск похожих по эмбеддингам
+	Write   time.Duration // Обновление счетчиков
+}
+
+func timeoutsFromEnv() Timeouts {
+	return Timeouts{
+		Read:    config.Duration("DB_TIMEOUT_READ", 5*time.Second),
+		Search:  config.Duration("DB_TIMEOUT_SEARCH", 10*time.Second),
+		Similar: config.Duration("DB_TIMEOUT_SIMILAR", 5*time.Second),
+		Write:   config.Duration("DB_TIMEOUT_WRITE", 10*time.Second),
+	}
 }
 
 type newsDB struct {
-	ID          uint64          `db:"id"`
-	Title       string          `db:"title"`
-	Description sql.NullString  `db:"description"`
-	FullText    sql.NullString  `db:"full_text"`
-	Time        time.Time       `db:"time"`
-	Enclosure   sql.NullString  `db:"enclosure"`
-	ViewsCount  uint64          `db:"views_count"`
-	SourcesJSON json.RawMessage `db:"sources_json"` // Здесь будет JSON-массив источников
+	ID           uint64           `db:"id"`
+	Title        string           `db:"title"`
+	Description  model.NullString `db:"description"`
+	FullText     model.NullString `db:"full_text"`
+	Time         time.Time        `db:"time"`
+	Enclosure    model.NullString `db:"enclosure"`
+	FeedID       uint64           `db:"feed_id"`
+	ViewsCount   uint64           `db:"views_count"`
+	StoryID      *uint64          `db:"story_id"`
+	Category     *string          `db:"category"`
+	SourcesJSON  json.RawMessage  `db:"sources_json"` // Здесь будет JSON-массив источников
+	SourcesTotal int              `db:"sources_total"`
 }
 
+// listSelect — общая часть запросов списков: заголовок и описание берутся из основного
+// источника группы, метаданные источника — из реестра sources.
+// listColumns и listFrom нужны запросам, которые добавляют к списку свои колонки.
+const listSelect = listColumns + listFrom
+
+const listColumns = `
+        SELECT 
+            groups.id, 
+            groups.time, 
+            feed.title, 
+            feed.description, 
+            feed.source_name,
+            groups.is_rt,
+            groups.views AS views_count,
+            groups.cover AS enclosure,
+            groups.story_id,
+            groups.category,
+            feed.source_name AS "source.name",
+            COALESCE(sources.display_name, feed.source_name) AS "source.display_name",
+            sources.logo_url AS "source.logo_url",
+            sources.site_url AS "source.site_url",
+            sources.region AS "source.region",
+            sources.language AS "source.language",
+            COALESCE(sources.priority, 0) AS "source.priority",
+            COALESCE(sources.trust_level, 0) AS "source.trust_level"`
+
+const listFrom = `
+        FROM groups
+        JOIN feed ON groups.feed_id = feed.id
+        LEFT JOIN sources ON sources.name = feed.source_name`
+
 func New(logger interfaces.Logger) (*DB, error) {
 
 	connectionData := fmt.Sprintf("user=%s dbname=%s sslmode=disable password=%s host=%s port=%s", os.Getenv("DB_LOGIN"), "newagregator", os.Getenv("DB_PASSWORD"), os.Getenv("DB_HOST"), os.Getenv("DB_PORT"))
-	db, err := sqlx.Connect("postgres", connectionData)
+	// statement_timeout — страховка на стороне сервера на случай, если отмена по контексту не дойдет
+	if timeout := config.Duration("DB_STATEMENT_TIMEOUT", 30*time.Second); timeout > 0 {
+		connectionData += fmt.Sprintf(" statement_timeout=%d", timeout.Milliseconds())
+	}
+	// Оборачиваем драйвер, чтобы каждый SQL-запрос создавал дочерний спан
+	sqlDB, err := otelsql.Open("postgres", connectionData, otelsql.WithAttributes(semconv.DBSystemNamePostgreSQL))
+	if err != nil {
+		return nil, err
+	}
+	db := sqlx.NewDb(sqlDB, "postgres")
+	err = db.Ping()
 
 	return &DB{
-		db:     db,
-		logger: logger,
+		db:       db,
+		logger:   logger,
+		breaker:  breaker.New(config.Int("DB_BREAKER_FAILURES", 5), config.Duration("DB_BREAKER_COOLDOWN", 30*time.Second)),
+		timeouts: timeoutsFromEnv(),
 	}, err
 }
 
-func (g *DB) GetLastIndex() (uint64, error) {
-	var index uint64
-	err := g.db.QueryRow("SELECT MAX(id) FROM groups").Scan(&index)
+func (g *DB) GetLastIndex(ctx context.Context) (index uint64, err error) {
+	ctx, finish, err := g.begin(ctx, g.timeouts.Read)
+	if err != nil {
+		return 0, err
+	}
+	defer finish(&err)
+
+	err = g.db.QueryRowContext(ctx, "SELECT MAX(id) FROM groups").Scan(&index)
 	if err != nil {
-		g.logger.Error("Error getting last index", "error", err)
+		g.logger.ErrorContext(ctx, "Error getting last index", "error", err)
 		return 0, err
 	}
 	return index, nil
 }
 
-func (g *DB) Get(lastDate time.Time, limit uint64, search ...string) ([]model.List, error) {
+func (g *DB) Get(ctx context.Context, lastDate time.Time, limit uint64, filter ListFilter, search ...string) (groups []model.List, err error) {
+	timeout := g.timeouts.Read
+	if len(search) > 0 && search[0] != "" {
+		timeout = g.timeouts.Search
+	}
+	ctx, finish, err := g.begin(ctx, timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer finish(&err)
+
 	// Базовый SQL-запрос
-	baseReq := `
-        SELECT 
-            groups.id, 
-            groups.time, 
-            feed.title, 
-            feed.description, 
-			feed.source_name,
-            groups.is_rt,
-            (
-                SELECT feed.enclosure
-                FROM compares
-                JOIN feed ON feed.id = compares.feed_id
-                WHERE compares.group_id = groups.id 
-                  AND feed.enclosure IS NOT NULL 
-                  AND feed.enclosure != ''
-                LIMIT 1
-            ) AS enclosure
-        FROM groups
-        JOIN feed ON groups.feed_id = feed.id
+	baseReq := listSelect + `
         WHERE groups.time < $1
     `
 
@@ -94,6 +163,9 @@ func (g *DB) Get(lastDate time.Time, limit uint64, search ...string) ([]model.Li
 		baseReq += ` AND (` + strings.Join(whereClauses, ` OR `) + `)`
 	}
 
+	clause, args := filter.where(args)
+	baseReq += clause
+
 	// Добавляем сортировку и лимит
 	baseReq += `
         ORDER BY groups.time DESC
@@ -101,45 +173,42 @@ func (g *DB) Get(lastDate time.Time, limit uint64, search ...string) ([]model.Li
 	args = append(args, limit)
 
 	// Выполняем запрос
-	stmt, err := g.db.Preparex(baseReq)
+	stmt, err := g.db.PreparexContext(ctx, baseReq)
 	if err != nil {
-		g.logger.Error("Error preparing statement", "error", err.Error())
+		g.logger.ErrorContext(ctx, "Error preparing statement", "error", err.Error())
 		return nil, err
 	}
 	defer stmt.Close()
 
-	var groups []model.List
-	err = stmt.Select(&groups, args...)
+	err = stmt.SelectContext(ctx, &groups, args...)
 	if err != nil {
-		g.logger.Error("Error executing statement", "error", err.Error())
+		g.logger.ErrorContext(ctx, "Error executing statement", "error", err.Error())
 		return nil, err
 	}
 
 	return groups, nil
 }
 
-func (g *DB) GetTopGroupsByFeedCount(limit uint64) ([]model.List, error) {
-	req := `
-        SELECT 
-            groups.id, 
-            groups.time, 
-            feed.title, 
-            feed.description, 
-			feed.source_name,
-            groups.is_rt, 
-            (
-                SELECT feed.enclosure
-                FROM compares
-                JOIN feed ON feed.id = compares.feed_id
-                WHERE compares.group_id = groups.id 
-                  AND feed.enclosure IS NOT NULL 
-                  AND feed.enclosure != ''
-                LIMIT 1
-            ) AS enclosure
-        FROM groups
-        JOIN feed ON groups.feed_id = feed.id
-        WHERE groups.time >= NOW() - INTERVAL '27 HOURS'
-        GROUP BY groups.id, feed.title, feed.description, groups.time, groups.is_rt, feed.source_name, enclosure
+// TopWindow — период, за который считается топ, если границы не заданы
+const TopWindow = 27 * time.Hour
+
+func (g *DB) GetTopGroupsByFeedCount(ctx context.Context, limit uint64, filter ListFilter) (groups []model.List, err error) {
+	ctx, finish, err := g.begin(ctx, g.timeouts.Read)
+	if err != nil {
+		return nil, err
+	}
+	defer finish(&err)
+
+	// Без нижней границы топ считается за TopWindow до верхней (или до текущего момента)
+	switch {
+	case !filter.bounded():
+		filter.Window = TopWindow
+	case filter.From.IsZero() && filter.Window == 0:
+		filter.From = filter.To.Add(-TopWindow)
+	}
+	clause, args := filter.where([]interface{}{limit})
+	req := listSelect + `
+        WHERE TRUE` + clause + `
         ORDER BY (
             SELECT COUNT(*)
             FROM compares
@@ -149,220 +218,385 @@ func (g *DB) GetTopGroupsByFeedCount(limit uint64) ([]model.List, error) {
         LIMIT $1
     `
 
-	stmt, err := g.db.Preparex(req)
+	stmt, err := g.db.PreparexContext(ctx, req)
 	if err != nil {
-		g.logger.Error("Error preparing query", "error", err.Error())
+		g.logger.ErrorContext(ctx, "Error preparing query", "error", err.Error())
 		return nil, err
 	}
 	defer stmt.Close()
 
-	var groups []model.List
-	err = stmt.Select(&groups, limit)
+	err = stmt.SelectContext(ctx, &groups, args...)
 	if err != nil {
-		g.logger.Error("Error executing query", "error", err.Error())
+		g.logger.ErrorContext(ctx, "Error executing query", "error", err.Error())
 		return nil, err
 	}
 
 	return groups, nil
 }
 
-func (g *DB) GetRTGroups(limit uint64, is_rt bool) ([]model.List, error) {
-	req := `
-        SELECT 
-            groups.id, 
-            groups.time, 
-            feed.title, 
-            feed.description, 
-			feed.source_name,
-            groups.is_rt,
-            (
-                SELECT COALESCE(feed.enclosure, '')
-                FROM compares
-                JOIN feed ON feed.id = compares.feed_id
-                WHERE compares.group_id = groups.id 
-                  AND feed.enclosure IS NOT NULL 
-                  AND feed.enclosure != ''
-                LIMIT 1
-            ) AS enclosure
-        FROM groups
-        JOIN feed ON groups.feed_id = feed.id
-        WHERE groups.is_rt = $1
+func (g *DB) GetRTGroups(ctx context.Context, limit uint64, is_rt bool, filter ListFilter) (groups []model.List, err error) {
+	ctx, finish, err := g.begin(ctx, g.timeouts.Read)
+	if err != nil {
+		return nil, err
+	}
+	defer finish(&err)
+
+	clause, args := filter.where([]interface{}{is_rt, limit})
+	req := listSelect + `
+        WHERE groups.is_rt = $1` + clause + `
         ORDER BY groups.time DESC
         LIMIT $2
     `
 
-	stmt, err := g.db.Preparex(req)
+	stmt, err := g.db.PreparexContext(ctx, req)
 	if err != nil {
-		g.logger.Error("Error preparing query", "error", err.Error())
+		g.logger.ErrorContext(ctx, "Error preparing query", "error", err.Error())
 		return nil, err
 	}
 	defer stmt.Close()
 
-	var groups []model.List
-	err = stmt.Select(&groups, is_rt, limit)
+	err = stmt.SelectContext(ctx, &groups, args...)
 	if err != nil {
-		g.logger.Error("Error executing query", "error", err.Error())
+		g.logger.ErrorContext(ctx, "Error executing query", "error", err.Error())
 		return nil, err
 	}
 
 	return groups, nil
 }
 
-func (g *DB) GetSimilarGroups(id, limit uint64) ([]model.List, error) {
-	req := `SELECT
-            g.id,
-            g.time,
-            g.is_rt,
-            feed.title,
-            feed.description,
-			feed.source_name,
-            (
-                SELECT COALESCE(feed.enclosure, '')
-                FROM compares
-                JOIN feed ON feed.id = compares.feed_id
-                WHERE compares.group_id = g.id 
-                  AND feed.enclosure IS NOT NULL 
-                  AND feed.enclosure != ''
-                LIMIT 1
-            ) AS enclosure
-        FROM
-            groups g
-        JOIN
-            feed ON feed.id = g.feed_id
-        WHERE
-            g.id <> $1
-        ORDER BY
-            1 - (g.embedding <=> (SELECT embedding FROM groups WHERE id = $1)) DESC,
-            g.time DESC
-        LIMIT $2`
-
-	var groups []model.List
-	err := g.db.Select(&groups, req, id, limit)
-	if err != nil {
-		g.logger.Error("Error executing query", "error", err.Error())
-		return nil, err
-	}
+// NewsColumns — что читать при выборке группы: какие полные тексты и сколько источников.
+// Нулевое значение не читает ни одного текста, FullNews — все тексты и все источники.
+type NewsColumns struct {
+	Rewrite      bool // groups.full_text
+	SourceText   bool // feed.full_text каждого источника
+	SourcesLimit int  // Сколько первых источников вернуть; 0 — все
+}
 
-	return groups, nil
+// FullNews — выборка группы со всеми текстами
+var FullNews = NewsColumns{Rewrite: true, SourceText: true}
+
+// Key возвращает суффикс ключа кэша; для полной выборки — пустую строку
+func (c NewsColumns) Key() string {
+	var key string
+	if c.Rewrite != FullNews.Rewrite || c.SourceText != FullNews.SourceText {
+		key = ":text"
+		if c.Rewrite {
+			key += "-rewrite"
+		}
+		if c.SourceText {
+			key += "-sources"
+		}
+	}
+	if c.SourcesLimit > 0 {
+		key += ":sources" + strconv.Itoa(c.SourcesLimit)
+	}
+	return key
 }
 
-// GetByID теперь получает группу и все ее источники за один запрос
-func (g *DB) GetByID(id uint64) (model.News, error) {
-	req := `
-    SELECT
-        g.id,
-        fc.title,
-        fc.description,
-        fc.full_text,
-        g.time,
-        g.views,
-        (
-            SELECT COALESCE(f_enc.enclosure, '')
-            FROM compares AS c_enc
-            JOIN feed AS f_enc ON f_enc.id = c_enc.feed_id
-            WHERE c_enc.group_id = g.id
-              AND f_enc.enclosure IS NOT NULL
-              AND f_enc.enclosure != ''
-            LIMIT 1
-        ) AS enclosure,
-        COALESCE(
-            json_agg(
+// sourceJSON — источник группы в виде model.Source; ожидает псевдонимы g (groups), fc (feed) и s (sources)
+const sourceJSON = `
                 json_build_object(
+                    'id', fc.id,
                     'title', fc.title,
                     'link', fc.link,
                     'name', fc.source_name,
                     'pubDate', fc.time,
                     'description', fc.description,
-                    'fullText', fc.full_text,
-                    'enclosure', fc.enclosure
-                ) ORDER BY fc.time DESC, fc.id
-            ) FILTER (WHERE fc.id IS NOT NULL),
-        '[]'::json) AS sources_json
+                    'full_text', {source_text},
+                    'enclosure', fc.enclosure,
+                    'primary', fc.id = g.feed_id,
+                    'source', json_build_object(
+                        'name', fc.source_name,
+                        'displayName', COALESCE(s.display_name, fc.source_name),
+                        'logoUrl', s.logo_url,
+                        'siteUrl', s.site_url,
+                        'region', s.region,
+                        'language', s.language,
+                        'priority', COALESCE(s.priority, 0),
+                        'trustLevel', COALESCE(s.trust_level, 0)
+                    )
+                )`
+
+// sourceText возвращает выражение для полного текста источника
+func sourceText(withText bool) string {
+	if withText {
+		return "fc.full_text"
+	}
+	return "NULL::text"
+}
+
+// newsSelect — запрос группы с ее источниками (новые первыми); дописывается WHERE.
+// Заголовок, описание и rewrite берутся из самой группы (при пустом заголовке —
+// из основного источника groups.feed_id), а основной источник помечается в sources.
+// Полные тексты, не указанные в columns, не читаются.
+func newsSelect(columns NewsColumns) string {
+	rewrite, limit := "NULL::text", ""
+	if columns.Rewrite {
+		rewrite = "g.full_text"
+	}
+	if columns.SourcesLimit > 0 {
+		limit = "LIMIT " + strconv.Itoa(columns.SourcesLimit)
+	}
+	return strings.NewReplacer(
+		"{rewrite}", rewrite,
+		"{source}", sourceJSON,
+		"{source_text}", sourceText(columns.SourceText),
+		"{sources_limit}", limit,
+	).Replace(newsSelectTemplate)
+}
+
+const newsSelectTemplate = `
+    SELECT
+        g.id,
+        COALESCE(NULLIF(g.title, ''), pf.title) AS title,
+        COALESCE(g.description, pf.description) AS description,
+        {rewrite} AS full_text,
+        g.time,
+        g.feed_id,
+        g.views AS views_count,
+        g.cover AS enclosure,
+        g.story_id,
+        g.category,
+        COALESCE((
+            SELECT json_agg(page.source ORDER BY page.time DESC, page.id)
+            FROM (
+                SELECT {source} AS source, fc.time, fc.id
+                FROM compares AS cp
+                JOIN feed AS fc ON fc.id = cp.feed_id
+                LEFT JOIN sources AS s ON s.name = fc.source_name
+                WHERE cp.group_id = g.id
+                ORDER BY fc.time DESC, fc.id
+                {sources_limit}
+            ) AS page
+        ), '[]'::json) AS sources_json,
+        (SELECT COUNT(*) FROM compares WHERE compares.group_id = g.id) AS sources_total
     FROM
         groups AS g
     LEFT JOIN
-        compares AS cp ON cp.group_id = g.id
-    LEFT JOIN
-        feed AS fc ON fc.id = cp.feed_id
+        feed AS pf ON pf.id = g.feed_id`
+
+// news собирает model.News из строки newsSelect
+func (n newsDB) news() (model.News, error) {
+	var sources []model.Source
+	// Демаршалируем JSON-массив источников
+	if len(n.SourcesJSON) > 0 {
+		if err := json.Unmarshal(n.SourcesJSON, &sources); err != nil {
+			return model.News{}, fmt.Errorf("failed to parse sources for group %d: %w", n.ID, err)
+		}
+	}
+
+	news := model.News{
+		ID:            n.ID,
+		Title:         n.Title,
+		Description:   n.Description,
+		FullText:      n.FullText,
+		Time:          n.Time,
+		Enclosure:     n.Enclosure,
+		PrimaryFeedID: n.FeedID,
+		ViewsCount:    n.ViewsCount,
+		StoryID:       n.StoryID,
+		Category:      n.Category,
+		Sources:       sources,
+		SourcesTotal:  n.SourcesTotal,
+	}
+	// Если источники вернулись не все, отдаем курсор для /get/:id/sources
+	if len(sources) > 0 && len(sources) < n.SourcesTotal {
+		last := sources[len(sources)-1]
+		news.SourcesCursor = SourceCursor{Time: last.Time, ID: last.ID}.Encode()
+	}
+	return news, nil
+}
+
+// GetByID получает группу и все ее источники за один запрос
+func (g *DB) GetByID(ctx context.Context, id uint64, columns NewsColumns) (group model.News, err error) {
+	ctx, finish, err := g.begin(ctx, g.timeouts.Read)
+	if err != nil {
+		return model.News{}, err
+	}
+	defer finish(&err)
+
+	req := newsSelect(columns) + `
     WHERE
-        g.id = $1
-    GROUP BY
-        g.id, g.title, g.description, g.full_text, g.time, g.views`
+        g.id = $1`
 
 	var dbNews newsDB
-	err := g.db.Get(&dbNews, req, id)
+	err = g.db.GetContext(ctx, &dbNews, req, id)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return model.News{}, fmt.Errorf("group with ID %d not found: %w", id, err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.News{}, fmt.Errorf("group with ID %d: %w", id, ErrNotFound)
 		}
-		g.logger.Error("Error executing query", "error", err.Error())
+		g.logger.ErrorContext(ctx, "Error executing query", "error", err.Error())
 		return model.News{}, fmt.Errorf("failed to query group %d: %w", id, err)
 	}
 
-	var sources []model.Source
-	// Демаршалируем JSON-массив источников
-	if len(dbNews.SourcesJSON) > 0 {
-		err = json.Unmarshal(dbNews.SourcesJSON, &sources)
+	group, err = dbNews.news()
+	if err != nil {
+		g.logger.ErrorContext(ctx, "Error parsing sources for group", "error", err.Error(), "id", id)
+		return model.News{}, err
+	}
+	return group, nil
+}
+
+// GetByIDs получает группы с источниками одним запросом; отсутствующие идентификаторы пропускаются
+func (g *DB) GetByIDs(ctx context.Context, ids []uint64, columns NewsColumns) (groups []model.News, err error) {
+	ctx, finish, err := g.begin(ctx, g.timeouts.Read)
+	if err != nil {
+		return nil, err
+	}
+	defer finish(&err)
+
+	req := newsSelect(columns) + `
+    WHERE
+        g.id = ANY($1)`
+
+	var rows []newsDB
+	err = g.db.SelectContext(ctx, &rows, req, int64Array(ids))
+	if err != nil {
+		g.logger.ErrorContext(ctx, "Error executing query", "error", err.Error())
+		return nil, err
+	}
+
+	groups = make([]model.News, 0, len(rows))
+	for _, row := range rows {
+		group, err := row.news()
 		if err != nil {
-			g.logger.Error("Error parsing sources for group", "error", err.Error(), "id", id)
-			// В случае ошибки демаршалинга, можно вернуть ошибку или пустой слайс sources
-			// В данном случае, возвращаем ошибку, так как это может быть критично.
-			return model.News{}, fmt.Errorf("failed to parse sources for group %d: %w", id, err)
+			g.logger.ErrorContext(ctx, "Error parsing sources for group", "error", err.Error(), "id", row.ID)
+			return nil, err
 		}
+		groups = append(groups, group)
 	}
+	return groups, nil
+}
 
-	// Собираем конечную структуру model.News
-	group := model.News{
-		ID:          dbNews.ID,
-		Title:       dbNews.Title,
-		Description: dbNews.Description, // Уже sql.NullString
-		FullText:    dbNews.FullText,    // Уже sql.NullString
-		Time:        dbNews.Time,
-		Enclosure:   dbNews.Enclosure, // Уже sql.NullString
-		ViewsCount:  dbNews.ViewsCount,
-		Sources:     sources,
+// GetListByIDs получает элементы списка для указанных групп; отсутствующие пропускаются
+func (g *DB) GetListByIDs(ctx context.Context, ids []uint64) (groups []model.List, err error) {
+	ctx, finish, err := g.begin(ctx, g.timeouts.Read)
+	if err != nil {
+		return nil, err
 	}
+	defer finish(&err)
 
-	return group, nil
+	err = g.db.SelectContext(ctx, &groups, listSelect+`
+        WHERE groups.id = ANY($1)`, int64Array(ids))
+	if err != nil {
+		g.logger.ErrorContext(ctx, "Error executing query", "error", err.Error())
+		return nil, err
+	}
+	return groups, nil
+}
+
+// GetViews возвращает сохраненные в БД счетчики просмотров для указанных групп
+func (g *DB) GetViews(ctx context.Context, ids []uint64) (views map[uint64]uint64, err error) {
+	ctx, finish, err := g.begin(ctx, g.timeouts.Read)
+	if err != nil {
+		return nil, err
+	}
+	defer finish(&err)
+
+	rows, err := g.db.QueryxContext(ctx, `SELECT id, views FROM groups WHERE id = ANY($1)`, int64Array(ids))
+	if err != nil {
+		g.logger.ErrorContext(ctx, "Error executing query", "error", err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	views = make(map[uint64]uint64, len(ids))
+	for rows.Next() {
+		var id, count uint64
+		if err = rows.Scan(&id, &count); err != nil {
+			return nil, err
+		}
+		views[id] = count
+	}
+	return views, rows.Err()
 }
 
-func (g *DB) IncrementVies(id uint64) error {
+func (g *DB) IncrementVies(ctx context.Context, id uint64) (err error) {
+	ctx, finish, err := g.begin(ctx, g.timeouts.Write)
+	if err != nil {
+		return err
+	}
+	defer finish(&err)
+
 	req := `UPDATE groups SET views = views + 1 WHERE id = $1`
-	_, err := g.db.Exec(req, id)
+	_, err = g.db.ExecContext(ctx, req, id)
 	if err != nil {
-		g.logger.Error("Error executing query", "error", err.Error())
+		g.logger.ErrorContext(ctx, "Error executing query", "error", err.Error())
 		return err
 	}
 	return nil
 }
 
-func (g *DB) UpdateViews(id uint64, views uint64) error {
+func (g *DB) UpdateViews(ctx context.Context, id uint64, views uint64) (err error) {
+	ctx, finish, err := g.begin(ctx, g.timeouts.Write)
+	if err != nil {
+		return err
+	}
+	defer finish(&err)
+
 	req := `UPDATE groups SET views = views + $1 WHERE id = $2`
-	_, err := g.db.Exec(req, views, id)
+	_, err = g.db.ExecContext(ctx, req, views, id)
 	if err != nil {
-		g.logger.Error("Error executing query", "error", err.Error())
+		g.logger.ErrorContext(ctx, "Error executing query", "error", err.Error())
 		return err
 	}
 	return nil
 }
 
-func (g *DB) UpdateViewsBatch(views map[int64]int64) error {
-	tx, err := g.db.Begin()
+func (g *DB) UpdateViewsBatch(ctx context.Context, views map[int64]int64) (err error) {
+	ctx, finish, err := g.begin(ctx, g.timeouts.Write)
 	if err != nil {
-		g.logger.Error("Error starting transaction", "error", err.Error())
+		return err
+	}
+	defer finish(&err)
+
+	tx, err := g.db.BeginTx(ctx, nil)
+	if err != nil {
+		g.logger.ErrorContext(ctx, "Error starting transaction", "error", err.Error())
 		return err
 	}
 	for id, views := range views {
-		_, err := tx.Exec(`UPDATE groups SET views = views + $1 WHERE id = $2`, views, id)
+		_, err = tx.ExecContext(ctx, `UPDATE groups SET views = views + $1 WHERE id = $2`, views, id)
 		if err != nil {
-			g.logger.Error("Error updating views", "error", err.Error())
+			g.logger.ErrorContext(ctx, "Error updating views", "error", err.Error())
 			return err
 		}
 	}
 	err = tx.Commit()
 	if err != nil {
-		g.logger.Error("Error committing transaction", "error", err.Error())
+		g.logger.ErrorContext(ctx, "Error committing transaction", "error", err.Error())
 		return err
 	}
 	return nil
 }
+
+// Ping проверяет доступность базы данных
+func (g *DB) Ping(ctx context.Context) error {
+	return g.db.PingContext(ctx)
+}
+
+// SchemaVersion возвращает текущую версию схемы из таблицы schema_migrations.
+// Если таблицы еще нет, возвращается версия 0.
+func (g *DB) SchemaVersion(ctx context.Context) (int64, bool, error) {
+	var version int64
+	var dirty bool
+	err := g.db.QueryRowContext(ctx, `SELECT version, dirty FROM schema_migrations ORDER BY version DESC LIMIT 1`).Scan(&version, &dirty)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.Is(err, sql.ErrNoRows) || (errors.As(err, &pqErr) && pqErr.Code == "42P01") {
+			return 0, false, nil
+		}
+		g.logger.ErrorContext(ctx, "Error getting schema version", "error", err.Error())
+		return 0, false, err
+	}
+	return version, dirty, nil
+}
+
+// int64Array приводит идентификаторы к массиву, который понимает драйвер Postgres
+func int64Array(ids []uint64) pq.Int64Array {
+	arr := make(pq.Int64Array, len(ids))
+	for i, id := range ids {
+		arr[i] = int64(id)
+	}
+	return arr
+}
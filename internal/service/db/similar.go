@@ -0,0 +1,162 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	model "agregator/api/internal/model/db"
+	"agregator/api/internal/pkg/config"
+	"agregator/api/internal/pkg/ranking"
+)
+
+// SimilarOptions — параметры подбора похожих групп
+type SimilarOptions struct {
+	MinScore  *float64      // Нижняя граница косинусного сходства
+	MaxAge    time.Duration // Наибольшая разница во времени с исходной группой
+	ExcludeRT bool          // Не показывать группы RT
+
+	MMR    bool    // Переупорядочить по maximal marginal relevance ради разнообразия
+	Lambda float64 // Баланс релевантности и разнообразия для MMR (1 — только релевантность)
+}
+
+// Key возвращает стабильное представление параметров для ключей кэша
+func (o SimilarOptions) Key() string {
+	var parts []string
+	if o.MinScore != nil {
+		parts = append(parts, "min="+strconv.FormatFloat(*o.MinScore, 'g', -1, 64))
+	}
+	if o.MaxAge > 0 {
+		parts = append(parts, "age="+o.MaxAge.String())
+	}
+	if o.ExcludeRT {
+		parts = append(parts, "nort")
+	}
+	if o.MMR {
+		parts = append(parts, "mmr="+strconv.FormatFloat(o.Lambda, 'g', -1, 64))
+	}
+	return strings.Join(parts, ";")
+}
+
+type similarRow struct {
+	model.List
+	Embedding model.Vector `db:"embedding"`
+}
+
+// similarMaxEfSearch — предел hnsw.ef_search в pgvector
+const similarMaxEfSearch = 1000
+
+// GetSimilarGroups возвращает группы, ближайшие к группе id по эмбеддингу, со значением сходства.
+// При opts.MMR из расширенного набора кандидатов выбираются разнообразные группы.
+func (g *DB) GetSimilarGroups(ctx context.Context, id, limit uint64, filter ListFilter, opts SimilarOptions) (groups []model.List, err error) {
+	ctx, finish, err := g.begin(ctx, g.timeouts.Similar)
+	if err != nil {
+		return nil, err
+	}
+	defer finish(&err)
+
+	tx, err := g.db.BeginTxx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Эмбеддинг исходной группы передается параметром: сортировка по расстоянию до
+	// константы — условие, при котором планировщик использует HNSW-индекс
+	var ref struct {
+		Embedding model.Vector `db:"embedding"`
+		Time      time.Time    `db:"time"`
+	}
+	err = tx.GetContext(ctx, &ref, `SELECT embedding::text AS embedding, time FROM groups WHERE id = $1`, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("group with ID %d: %w", id, ErrNotFound)
+	} else if err != nil {
+		g.logger.ErrorContext(ctx, "Error executing query", "error", err.Error())
+		return nil, err
+	}
+	if ref.Embedding == nil || ref.Embedding.IsZero() {
+		return []model.List{}, nil
+	}
+
+	candidates := limit
+	columns := `,
+            1 - (groups.embedding <=> $3::vector) AS score`
+	if opts.MMR {
+		candidates = min(limit*config.Uint("SIMILAR_MMR_POOL", 4), 200)
+		columns += `,
+            groups.embedding::text AS embedding`
+	}
+
+	clause, args := filter.where([]interface{}{id, candidates, ref.Embedding})
+	filtered := clause != ""
+	if opts.MinScore != nil {
+		args = append(args, 1-*opts.MinScore)
+		clause += `
+          AND groups.embedding <=> $3::vector <= $` + strconv.Itoa(len(args))
+	}
+	if opts.MaxAge > 0 {
+		args = append(args, ref.Time.Add(-opts.MaxAge), ref.Time.Add(opts.MaxAge))
+		clause += `
+          AND groups.time BETWEEN $` + strconv.Itoa(len(args)-1) + ` AND $` + strconv.Itoa(len(args))
+	}
+	if opts.ExcludeRT {
+		clause += `
+          AND NOT groups.is_rt`
+	}
+	filtered = filtered || opts.MinScore != nil || opts.MaxAge > 0 || opts.ExcludeRT
+
+	req := listColumns + columns + listFrom + `
+        WHERE
+            groups.id <> $1
+            AND groups.embedding IS NOT NULL` + clause + `
+        ORDER BY
+            groups.embedding <=> $3::vector,
+            groups.time DESC
+        LIMIT $2`
+
+	// HNSW-индекс отдает не больше hnsw.ef_search ближайших кандидатов, а условия WHERE
+	// применяются уже к ним, поэтому с фильтрами результат может оказаться короче limit.
+	// В таком случае запрос повторяется с большим ef_search, пока он не достигнет предела.
+	var rows []similarRow
+	for ef := min(max(2*candidates, 40), similarMaxEfSearch); ; ef = min(2*ef, similarMaxEfSearch) {
+		if _, err = tx.ExecContext(ctx, `SET LOCAL hnsw.ef_search = `+strconv.FormatUint(ef, 10)); err != nil {
+			g.logger.ErrorContext(ctx, "Error setting ef_search", "error", err.Error())
+			return nil, err
+		}
+		rows = rows[:0]
+		err = tx.SelectContext(ctx, &rows, req, args...)
+		if err != nil {
+			g.logger.ErrorContext(ctx, "Error executing query", "error", err.Error())
+			return nil, err
+		}
+		if !filtered || uint64(len(rows)) >= candidates || ef >= similarMaxEfSearch {
+			break
+		}
+	}
+
+	order := make([]int, len(rows))
+	for i := range order {
+		order[i] = i
+	}
+	if opts.MMR {
+		relevance := make([]float64, len(rows))
+		vectors := make([][]float32, len(rows))
+		for i, row := range rows {
+			if row.Score != nil {
+				relevance[i] = *row.Score
+			}
+			vectors[i] = row.Embedding
+		}
+		order = ranking.MMR(relevance, vectors, opts.Lambda, int(limit))
+	}
+
+	groups = make([]model.List, len(order))
+	for i, idx := range order {
+		groups[i] = rows[idx].List
+	}
+	return groups, nil
+}
@@ -0,0 +1,69 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	model "agregator/api/internal/model/db"
+)
+
+// GetSources возвращает реестр источников, сначала самые приоритетные
+func (g *DB) GetSources(ctx context.Context) (sources []model.SourceInfo, err error) {
+	ctx, finish, err := g.begin(ctx, g.timeouts.Read)
+	if err != nil {
+		return nil, err
+	}
+	defer finish(&err)
+
+	req := `
+        SELECT name, display_name, logo_url, site_url, region, language, priority, trust_level
+        FROM sources
+        ORDER BY priority DESC, display_name`
+
+	err = g.db.SelectContext(ctx, &sources, req)
+	if err != nil {
+		g.logger.ErrorContext(ctx, "Error executing query", "error", err.Error())
+		return nil, err
+	}
+	return sources, nil
+}
+
+// GetBySource возвращает группы, в которых есть публикации источника name, новее lastDate
+func (g *DB) GetBySource(ctx context.Context, name string, lastDate time.Time, limit uint64, filter ListFilter) (groups []model.List, err error) {
+	ctx, finish, err := g.begin(ctx, g.timeouts.Read)
+	if err != nil {
+		return nil, err
+	}
+	defer finish(&err)
+
+	var exists bool
+	err = g.db.GetContext(ctx, &exists, `SELECT EXISTS (SELECT 1 FROM sources WHERE name = $1)`, name)
+	if err != nil {
+		g.logger.ErrorContext(ctx, "Error executing query", "error", err.Error())
+		return nil, err
+	}
+	if !exists {
+		return nil, fmt.Errorf("source %q: %w", name, ErrNotFound)
+	}
+
+	clause, args := filter.where([]interface{}{name, lastDate, limit})
+	req := listSelect + `
+        WHERE groups.time < $2
+          AND EXISTS (
+              SELECT 1
+              FROM compares
+              JOIN feed AS f ON f.id = compares.feed_id
+              WHERE compares.group_id = groups.id
+                AND f.source_name = $1
+          )` + clause + `
+        ORDER BY groups.time DESC
+        LIMIT $3`
+
+	err = g.db.SelectContext(ctx, &groups, req, args...)
+	if err != nil {
+		g.logger.ErrorContext(ctx, "Error executing query", "error", err.Error())
+		return nil, err
+	}
+	return groups, nil
+}
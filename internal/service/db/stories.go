@@ -0,0 +1,182 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	model "agregator/api/internal/model/db"
+)
+
+// storyLockID — ключ advisory-блокировки, чтобы сюжеты связывал только один экземпляр API
+const storyLockID = 7_390_112_035
+
+// StoryOptions — параметры связывания групп в сюжеты
+type StoryOptions struct {
+	MinScore float64       // Наименьшее косинусное сходство с предыдущей группой сюжета
+	Window   time.Duration // Насколько раньше может быть предыдущая группа
+	Lookback time.Duration // Более старые необработанные группы не рассматриваются
+	Batch    int           // Сколько групп обработать за один проход
+	Timeout  time.Duration // Ограничение времени на проход
+}
+
+// LinkStories обрабатывает очередную порцию групп в порядке времени. Каждая группа
+// присоединяется к сюжету ближайшей по эмбеддингу более ранней группы из окна opts.Window;
+// если у той еще нет сюжета, он создается. Возвращает число присоединенных групп.
+// Каждая группа обрабатывается в своей транзакции, чтобы блокировки строк groups
+// не задерживали запись агрегатора на весь проход.
+func (g *DB) LinkStories(ctx context.Context, opts StoryOptions) (linked int, err error) {
+	ctx, finish := g.beginJob(ctx, opts.Timeout)
+	defer finish(&err)
+
+	// Блокировка сессии держится на одном соединении весь проход, между транзакциями групп
+	conn, err := g.db.Connx(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+
+	var locked bool
+	if err = conn.GetContext(ctx, &locked, `SELECT pg_try_advisory_lock($1)`, storyLockID); err != nil {
+		return 0, err
+	}
+	if !locked {
+		return 0, nil
+	}
+	defer conn.ExecContext(context.WithoutCancel(ctx), `SELECT pg_advisory_unlock($1)`, storyLockID)
+
+	var ids []uint64
+	err = conn.SelectContext(ctx, &ids, `
+        SELECT id
+        FROM groups
+        WHERE story_checked_at IS NULL
+          AND embedding IS NOT NULL
+          AND time >= NOW() - make_interval(secs => $1)
+        ORDER BY time, id
+        LIMIT $2`, opts.Lookback.Seconds(), opts.Batch)
+	if err != nil {
+		g.logger.ErrorContext(ctx, "Error selecting groups to link", "error", err.Error())
+		return 0, err
+	}
+
+	for _, id := range ids {
+		ok, err := linkGroupTx(ctx, conn, id, opts)
+		if err != nil {
+			g.logger.ErrorContext(ctx, "Error linking group to story", "error", err.Error(), "id", id)
+			return linked, err
+		}
+		if ok {
+			linked++
+		}
+	}
+	return linked, nil
+}
+
+// linkGroupTx связывает одну группу в отдельной транзакции
+func linkGroupTx(ctx context.Context, conn *sqlx.Conn, id uint64, opts StoryOptions) (bool, error) {
+	tx, err := conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	linked, err := linkGroup(ctx, tx, id, opts)
+	if err != nil {
+		return false, err
+	}
+	return linked, tx.Commit()
+}
+
+func linkGroup(ctx context.Context, tx *sqlx.Tx, id uint64, opts StoryOptions) (bool, error) {
+	var prev struct {
+		ID      uint64        `db:"id"`
+		StoryID sql.NullInt64 `db:"story_id"`
+	}
+	err := tx.GetContext(ctx, &prev, `
+        SELECT p.id, p.story_id
+        FROM groups AS g
+        JOIN groups AS p
+          ON p.id <> g.id
+         AND p.embedding IS NOT NULL
+         AND p.time <= g.time
+         AND p.time >= g.time - make_interval(secs => $2)
+         AND p.embedding <=> g.embedding <= $3
+        WHERE g.id = $1
+          AND g.embedding IS NOT NULL
+        ORDER BY p.embedding <=> g.embedding
+        LIMIT 1`, id, opts.Window.Seconds(), 1-opts.MinScore)
+
+	linked := err == nil
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return false, err
+	}
+
+	if linked {
+		storyID := prev.StoryID.Int64
+		if !prev.StoryID.Valid {
+			err = tx.GetContext(ctx, &storyID, `
+                INSERT INTO stories (title, started_at, updated_at, groups_count)
+                SELECT COALESCE(NULLIF(g.title, ''), pf.title, ''), g.time, g.time, 1
+                FROM groups AS g
+                LEFT JOIN feed AS pf ON pf.id = g.feed_id
+                WHERE g.id = $1
+                RETURNING id`, prev.ID)
+			if err != nil {
+				return false, err
+			}
+			if _, err = tx.ExecContext(ctx, `UPDATE groups SET story_id = $1 WHERE id = $2`, storyID, prev.ID); err != nil {
+				return false, err
+			}
+		}
+		_, err = tx.ExecContext(ctx, `
+            WITH joined AS (
+                UPDATE groups SET story_id = $1 WHERE id = $2 AND story_id IS DISTINCT FROM $1
+                RETURNING time
+            )
+            UPDATE stories
+            SET groups_count = groups_count + 1,
+                updated_at = GREATEST(updated_at, joined.time)
+            FROM joined
+            WHERE stories.id = $1`, storyID, id)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE groups SET story_checked_at = NOW() WHERE id = $1`, id)
+	return linked, err
+}
+
+// GetStory возвращает сюжет с его группами в хронологическом порядке
+func (g *DB) GetStory(ctx context.Context, id uint64) (story model.Story, err error) {
+	ctx, finish, err := g.begin(ctx, g.timeouts.Read)
+	if err != nil {
+		return model.Story{}, err
+	}
+	defer finish(&err)
+
+	err = g.db.GetContext(ctx, &story, `
+        SELECT id, title, started_at, updated_at, groups_count
+        FROM stories
+        WHERE id = $1`, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.Story{}, fmt.Errorf("story with ID %d: %w", id, ErrNotFound)
+		}
+		g.logger.ErrorContext(ctx, "Error executing query", "error", err.Error())
+		return model.Story{}, err
+	}
+
+	err = g.db.SelectContext(ctx, &story.Groups, listSelect+`
+        WHERE groups.story_id = $1
+        ORDER BY groups.time, groups.id`, id)
+	if err != nil {
+		g.logger.ErrorContext(ctx, "Error executing query", "error", err.Error())
+		return model.Story{}, err
+	}
+	return story, nil
+}
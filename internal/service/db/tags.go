@@ -0,0 +1,175 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+
+	model "agregator/api/internal/model/db"
+)
+
+// GroupText — тексты группы для выделения ключевых слов и классификации
+type GroupText struct {
+	ID     uint64    `db:"id"`
+	Time   time.Time `db:"time"`
+	Titles string    `db:"titles"`
+	Text   string    `db:"text"`
+}
+
+// groupTextSelect выбирает GroupText: заголовки всех публикаций группы, ее rewrite,
+// описания и начала полных текстов публикаций. Запрос дописывает WHERE и GROUP BY groups.id.
+const groupTextSelect = `
+        SELECT
+            groups.id,
+            groups.time,
+            groups.title || E'\n' || COALESCE(string_agg(feed.title, E'\n'), '') AS titles,
+            COALESCE(groups.full_text, '') || E'\n' ||
+            COALESCE(string_agg(COALESCE(feed.description, '') || ' ' || LEFT(COALESCE(feed.full_text, ''), 5000), E'\n'), '') AS text
+        FROM groups
+        LEFT JOIN compares ON compares.group_id = groups.id
+        LEFT JOIN feed ON feed.id = compares.feed_id`
+
+// GetTagCorpus возвращает тексты групп новее since
+func (g *DB) GetTagCorpus(ctx context.Context, since time.Time, timeout time.Duration) (docs []GroupText, err error) {
+	ctx, finish, err := g.begin(ctx, timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer finish(&err)
+
+	tx, err := g.db.BeginTxx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	if err = setLocalTimeout(ctx, tx, timeout); err != nil {
+		return nil, err
+	}
+
+	err = tx.SelectContext(ctx, &docs, groupTextSelect+`
+        WHERE groups.time >= $1
+        GROUP BY groups.id`, since)
+	if err != nil {
+		g.logger.ErrorContext(ctx, "Error executing query", "error", err.Error())
+		return nil, err
+	}
+	return docs, tx.Commit()
+}
+
+// SaveTags заменяет теги указанных групп
+func (g *DB) SaveTags(ctx context.Context, tags map[uint64][]model.Tag, timeout time.Duration) (err error) {
+	ctx, finish, err := g.begin(ctx, timeout)
+	if err != nil {
+		return err
+	}
+	defer finish(&err)
+
+	ids := make([]uint64, 0, len(tags))
+	var groupIDs []int64
+	var names, labels []string
+	var scores []float64
+	for id, list := range tags {
+		ids = append(ids, id)
+		for _, t := range list {
+			groupIDs = append(groupIDs, int64(id))
+			names = append(names, t.Tag)
+			labels = append(labels, t.Label)
+			scores = append(scores, t.Score)
+		}
+	}
+
+	tx, err := g.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err = setLocalTimeout(ctx, tx, timeout); err != nil {
+		return err
+	}
+
+	if _, err = tx.ExecContext(ctx, `DELETE FROM tags WHERE group_id = ANY($1)`, int64Array(ids)); err != nil {
+		g.logger.ErrorContext(ctx, "Error deleting tags", "error", err.Error())
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `
+        INSERT INTO tags (group_id, tag, label, score)
+        SELECT * FROM unnest($1::bigint[], $2::text[], $3::text[], $4::float8[])
+        ON CONFLICT (group_id, tag) DO UPDATE SET label = EXCLUDED.label, score = EXCLUDED.score`,
+		pq.Array(groupIDs), pq.Array(names), pq.Array(labels), pq.Array(scores))
+	if err != nil {
+		g.logger.ErrorContext(ctx, "Error inserting tags", "error", err.Error())
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetTrendingTags возвращает теги, встречающиеся в наибольшем числе групп за последний window,
+// вместе с числом групп за предыдущий такой же период
+func (g *DB) GetTrendingTags(ctx context.Context, window time.Duration, limit uint64, filter ListFilter) (tags []model.TrendingTag, err error) {
+	ctx, finish, err := g.begin(ctx, g.timeouts.Read)
+	if err != nil {
+		return nil, err
+	}
+	defer finish(&err)
+
+	clause, args := filter.where([]interface{}{window.Seconds(), limit})
+	req := `
+        WITH recent AS (
+            SELECT tags.tag, MIN(tags.label) AS label, COUNT(*) AS groups, SUM(tags.score) AS score
+            FROM tags
+            JOIN groups ON groups.id = tags.group_id
+            WHERE groups.time >= NOW() - make_interval(secs => $1)` + clause + `
+            GROUP BY tags.tag
+        ),
+        previous AS (
+            SELECT tags.tag, COUNT(*) AS groups
+            FROM tags
+            JOIN groups ON groups.id = tags.group_id
+            WHERE groups.time >= NOW() - 2 * make_interval(secs => $1)
+              AND groups.time < NOW() - make_interval(secs => $1)
+              AND tags.tag IN (SELECT tag FROM recent)` + clause + `
+            GROUP BY tags.tag
+        )
+        SELECT recent.tag, recent.label, recent.groups, COALESCE(previous.groups, 0) AS prev_groups, recent.score
+        FROM recent
+        LEFT JOIN previous ON previous.tag = recent.tag
+        ORDER BY recent.groups DESC, recent.score DESC, recent.tag
+        LIMIT $2`
+
+	err = g.db.SelectContext(ctx, &tags, req, args...)
+	if err != nil {
+		g.logger.ErrorContext(ctx, "Error executing query", "error", err.Error())
+		return nil, err
+	}
+	return tags, nil
+}
+
+// GetByTag возвращает группы с тегом старше lastDate, сначала самые свежие.
+// Тег ищется точно как tag, а если такого нет — как fallback (тот же текст, приведенный к основам).
+func (g *DB) GetByTag(ctx context.Context, tag, fallback string, lastDate time.Time, limit uint64, filter ListFilter) (groups []model.List, err error) {
+	ctx, finish, err := g.begin(ctx, g.timeouts.Read)
+	if err != nil {
+		return nil, err
+	}
+	defer finish(&err)
+
+	clause, args := filter.where([]interface{}{tag, lastDate, limit, fallback})
+	req := listSelect + `
+        WHERE groups.time < $2
+          AND EXISTS (
+              SELECT 1 FROM tags
+              WHERE tags.group_id = groups.id
+                AND tags.tag = COALESCE((SELECT tag FROM tags WHERE tag = $1 LIMIT 1), $4)
+          )` + clause + `
+        ORDER BY groups.time DESC
+        LIMIT $3`
+
+	err = g.db.SelectContext(ctx, &groups, req, args...)
+	if err != nil {
+		g.logger.ErrorContext(ctx, "Error executing query", "error", err.Error())
+		return nil, err
+	}
+	return groups, nil
+}
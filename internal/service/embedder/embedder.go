@@ -0,0 +1,30 @@
+// Package embedder содержит реализации interfaces.Embedder для семантического поиска.
+package embedder
+
+import (
+	"os"
+
+	"agregator/api/internal/interfaces"
+	"agregator/api/internal/pkg/config"
+)
+
+// New выбирает реализацию по EMBEDDER: http (по умолчанию, если задан EMBEDDER_URL) или stub.
+// Если эмбеддер не настроен, возвращает nil — семантический поиск будет недоступен.
+func New(logger interfaces.Logger) interfaces.Embedder {
+	dim := config.Int("EMBEDDING_DIM", 768)
+	switch os.Getenv("EMBEDDER") {
+	case "stub":
+		logger.Warn("Using stub embedder, semantic search results are not meaningful")
+		return NewStub(dim)
+	case "", "http":
+		url := os.Getenv("EMBEDDER_URL")
+		if url == "" {
+			logger.Warn("EMBEDDER_URL is not set, semantic search is disabled")
+			return nil
+		}
+		return NewHTTP(url, dim, config.Duration("EMBEDDER_TIMEOUT", 0))
+	default:
+		logger.Error("Unknown embedder, semantic search is disabled", "embedder", os.Getenv("EMBEDDER"))
+		return nil
+	}
+}
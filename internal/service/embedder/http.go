@@ -0,0 +1,79 @@
+package embedder
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"agregator/api/internal/pkg/tracing"
+)
+
+// HTTP обращается к сервису эмбеддингов: POST {"input": "..."} -> {"embedding": [...]}
+type HTTP struct {
+	url    string
+	dim    int
+	client *http.Client
+}
+
+func NewHTTP(url string, dim int, timeout time.Duration) *HTTP {
+	if timeout <= 0 {
+		timeout = 3 * time.Second
+	}
+	return &HTTP{url: url, dim: dim, client: &http.Client{Timeout: timeout}}
+}
+
+func (h *HTTP) Embed(ctx context.Context, text string) (vec []float32, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "embedder.Embed",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.Int("embedder.input_length", len(text))),
+	)
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}()
+
+	body, err := json.Marshal(map[string]string{"input": text})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("embedder request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("embedder responded %d: %s", resp.StatusCode, bytes.TrimSpace(msg))
+	}
+
+	var out struct {
+		Embedding []float32 `json:"embedding"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("embedder response: %w", err)
+	}
+	if len(out.Embedding) != h.dim {
+		return nil, fmt.Errorf("embedder returned %d dimensions, expected %d", len(out.Embedding), h.dim)
+	}
+	return out.Embedding, nil
+}
@@ -0,0 +1,45 @@
+package embedder
+
+import (
+	"context"
+	"hash/fnv"
+	"math"
+
+	"agregator/api/internal/pkg/text"
+)
+
+// Stub — детерминированный эмбеддер для тестов и локальной разработки.
+// Каждое слово хэшируется в одну из координат, поэтому тексты с общими словами близки.
+type Stub struct {
+	dim int
+}
+
+func NewStub(dim int) *Stub {
+	return &Stub{dim: dim}
+}
+
+func (s *Stub) Embed(_ context.Context, input string) ([]float32, error) {
+	vec := make([]float32, s.dim)
+	for _, w := range text.Tokens(input) {
+		h := fnv.New64a()
+		h.Write([]byte(w))
+		sum := h.Sum64()
+		sign := float32(1)
+		if sum&1 == 1 {
+			sign = -1
+		}
+		vec[(sum>>1)%uint64(s.dim)] += sign
+	}
+
+	var norm float64
+	for _, v := range vec {
+		norm += float64(v) * float64(v)
+	}
+	if norm > 0 {
+		norm = math.Sqrt(norm)
+		for i := range vec {
+			vec[i] = float32(float64(vec[i]) / norm)
+		}
+	}
+	return vec, nil
+}
@@ -0,0 +1,127 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"time"
+)
+
+const (
+	ModeCache   = "cache"    // Redis доступен
+	ModeNoCache = "no-cache" // Redis недоступен: кэш пропускается, просмотры копятся в памяти
+
+	minReconnectDelay = 500 * time.Millisecond
+	maxReconnectDelay = 30 * time.Second
+
+	viewsTTL = 24 * time.Hour
+)
+
+// Stats — состояние кэша для проверок здоровья
+type Stats struct {
+	Mode           string `json:"mode"`
+	BufferedKeys   int    `json:"bufferedKeys"`
+	BufferedViews  int64  `json:"bufferedViews"`
+	DroppedViews   int64  `json:"droppedViews"`
+	ReconnectCount int64  `json:"reconnects"`
+}
+
+// Mode возвращает текущий режим работы кэша
+func (r *RedisCache) Mode() string {
+	if r.available.Load() {
+		return ModeCache
+	}
+	return ModeNoCache
+}
+
+// Stats возвращает режим работы и состояние буфера просмотров
+func (r *RedisCache) Stats() Stats {
+	r.mu.Lock()
+	var views int64
+	for _, v := range r.buffer {
+		views += v
+	}
+	keys := len(r.buffer)
+	r.mu.Unlock()
+
+	return Stats{
+		Mode:           r.Mode(),
+		BufferedKeys:   keys,
+		BufferedViews:  views,
+		DroppedViews:   r.dropped.Load(),
+		ReconnectCount: r.reconnects.Load(),
+	}
+}
+
+// isConnError отличает недоступность Redis от ошибок отдельных команд
+func isConnError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// check переводит кэш в режим без Redis, если ошибка говорит о потере соединения
+func (r *RedisCache) check(ctx context.Context, err error) error {
+	if isConnError(err) {
+		r.markDown(ctx, err)
+	}
+	return err
+}
+
+func (r *RedisCache) markDown(ctx context.Context, err error) {
+	if !r.available.CompareAndSwap(true, false) {
+		return
+	}
+	r.logger.WarnContext(ctx, "Redis unavailable, switching to no-cache mode", "error", err.Error())
+	go r.reconnect(context.WithoutCancel(ctx))
+}
+
+// reconnect пингует Redis с экспоненциальной задержкой и, дождавшись ответа,
+// переносит накопленные в памяти просмотры обратно в Redis
+func (r *RedisCache) reconnect(ctx context.Context) {
+	delay := minReconnectDelay
+	for {
+		// Случайный разброс, чтобы поды не переподключались синхронно
+		time.Sleep(delay/2 + rand.N(delay/2))
+		if err := r.client.WithContext(ctx).Ping().Err(); err == nil {
+			break
+		}
+		delay = min(delay*2, maxReconnectDelay)
+	}
+
+	r.reconnects.Add(1)
+	r.available.Store(true)
+	r.logger.InfoContext(ctx, "Redis is available again, replaying buffered views")
+	r.replayViews(ctx)
+}
+
+// bufferViews копит просмотры в памяти, пока Redis недоступен.
+// Буфер ограничен: новые ключи сверх лимита отбрасываются и учитываются в DroppedViews.
+func (r *RedisCache) bufferViews(id string, n int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.buffer[id]; !ok && len(r.buffer) >= r.bufferSize {
+		r.dropped.Add(n)
+		return
+	}
+	r.buffer[id] += n
+}
+
+func (r *RedisCache) replayViews(ctx context.Context) {
+	r.mu.Lock()
+	buffer := r.buffer
+	r.buffer = make(map[string]int64)
+	r.mu.Unlock()
+
+	for id, n := range buffer {
+		if err := r.incrViews(ctx, id, n); err != nil {
+			r.logger.ErrorContext(ctx, "Error replaying buffered views", "id", id, "error", err.Error())
+			r.bufferViews(id, n)
+		}
+	}
+}
@@ -0,0 +1,238 @@
+package redis
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"log/slog"
+	"maps"
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-redis/redis"
+)
+
+// newTestCache создает кэш без проверки соединения в New
+func newTestCache(addr string, bufferSize int) *RedisCache {
+	r := &RedisCache{
+		client:     redis.NewClient(&redis.Options{Addr: addr, MaxRetries: 0}),
+		logger:     slog.New(slog.NewTextHandler(io.Discard, nil)),
+		buffer:     make(map[string]int64),
+		bufferSize: bufferSize,
+	}
+	r.available.Store(true)
+	return r
+}
+
+func TestBufferViewsBounded(t *testing.T) {
+	r := newTestCache("127.0.0.1:0", 2)
+	r.bufferViews("1", 1)
+	r.bufferViews("2", 2)
+	// Новый ключ сверх лимита отбрасывается, уже известные продолжают копиться
+	r.bufferViews("3", 4)
+	r.bufferViews("1", 5)
+
+	if want := map[string]int64{"1": 6, "2": 2}; !reflect.DeepEqual(r.buffer, want) {
+		t.Errorf("buffer = %v, want %v", r.buffer, want)
+	}
+	stats := r.Stats()
+	if stats.BufferedKeys != 2 || stats.BufferedViews != 8 || stats.DroppedViews != 4 {
+		t.Errorf("Stats() = %+v, want 2 keys, 8 views, 4 dropped", stats)
+	}
+}
+
+func TestAddViewsWithoutRedis(t *testing.T) {
+	r := newTestCache("127.0.0.1:0", 10)
+	r.available.Store(false)
+
+	for i := 0; i < 3; i++ {
+		if err := r.IncViews(context.Background(), "7"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := r.AddViews(context.Background(), "8", 5); err != nil {
+		t.Fatal(err)
+	}
+	if r.Mode() != ModeNoCache {
+		t.Errorf("Mode() = %q, want %q", r.Mode(), ModeNoCache)
+	}
+	// Еще не сброшенные просмотры видны и без Redis
+	pending, err := r.PendingViews(context.Background(), []uint64{7, 8, 9})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[uint64]int64{7: 3, 8: 5}; !reflect.DeepEqual(pending, want) {
+		t.Errorf("PendingViews() = %v, want %v", pending, want)
+	}
+}
+
+func TestReplayViews(t *testing.T) {
+	server := newFakeRedis(t)
+	r := newTestCache(server.addr, 10)
+	r.bufferViews("1", 3)
+	r.bufferViews("2", 5)
+
+	r.replayViews(context.Background())
+
+	if len(r.buffer) != 0 {
+		t.Errorf("buffer after replay = %v, want empty", r.buffer)
+	}
+	if want := map[string]string{"views:1": "3", "views:2": "5"}; !reflect.DeepEqual(server.snapshot(), want) {
+		t.Errorf("Redis after replay = %v, want %v", server.snapshot(), want)
+	}
+	pending, err := r.PendingViews(context.Background(), []uint64{1, 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[uint64]int64{1: 3, 2: 5}; !reflect.DeepEqual(pending, want) {
+		t.Errorf("PendingViews() = %v, want %v", pending, want)
+	}
+}
+
+func TestReplayViewsKeepsFailed(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	r := newTestCache(addr, 10)
+	// Кэш уже в режиме без Redis, так что повторный markDown не запускает reconnect
+	r.available.Store(false)
+	r.bufferViews("1", 3)
+
+	r.replayViews(context.Background())
+
+	if want := map[string]int64{"1": 3}; !reflect.DeepEqual(r.buffer, want) {
+		t.Errorf("buffer after failed replay = %v, want %v", r.buffer, want)
+	}
+}
+
+// fakeRedis — Redis в памяти с командами, которые нужны для просмотров:
+// PING, GET, MGET, INCRBY, EXPIRE и транзакции MULTI/EXEC
+type fakeRedis struct {
+	addr string
+	mu   sync.Mutex
+	data map[string]string
+}
+
+func newFakeRedis(t *testing.T) *fakeRedis {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	f := &fakeRedis{addr: ln.Addr().String(), data: make(map[string]string)}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+	return f
+}
+
+func (f *fakeRedis) snapshot() map[string]string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return maps.Clone(f.data)
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	var queue [][]string
+	inTx := false
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+		var reply string
+		switch cmd := strings.ToUpper(args[0]); {
+		case cmd == "MULTI":
+			inTx, queue, reply = true, nil, "+OK\r\n"
+		case cmd == "EXEC":
+			reply = "*" + strconv.Itoa(len(queue)) + "\r\n"
+			for _, q := range queue {
+				reply += f.exec(q)
+			}
+			inTx = false
+		case inTx:
+			queue = append(queue, args)
+			reply = "+QUEUED\r\n"
+		default:
+			reply = f.exec(args)
+		}
+		if _, err := conn.Write([]byte(reply)); err != nil {
+			return
+		}
+	}
+}
+
+func (f *fakeRedis) exec(args []string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch strings.ToUpper(args[0]) {
+	case "PING":
+		return "+PONG\r\n"
+	case "GET":
+		return bulk(f.data, args[1])
+	case "MGET":
+		reply := "*" + strconv.Itoa(len(args)-1) + "\r\n"
+		for _, key := range args[1:] {
+			reply += bulk(f.data, key)
+		}
+		return reply
+	case "INCRBY":
+		cur, _ := strconv.ParseInt(f.data[args[1]], 10, 64)
+		by, _ := strconv.ParseInt(args[2], 10, 64)
+		f.data[args[1]] = strconv.FormatInt(cur+by, 10)
+		return ":" + f.data[args[1]] + "\r\n"
+	case "EXPIRE":
+		return ":1\r\n"
+	default:
+		return "-ERR unknown command\r\n"
+	}
+}
+
+func bulk(data map[string]string, key string) string {
+	v, ok := data[key]
+	if !ok {
+		return "$-1\r\n"
+	}
+	return "$" + strconv.Itoa(len(v)) + "\r\n" + v + "\r\n"
+}
+
+// readCommand читает команду RESP: *N, затем N пар $len / значение
+func readCommand(r *bufio.Reader) ([]string, error) {
+	header, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(header, "*")))
+	if err != nil || n < 1 {
+		return nil, io.ErrUnexpectedEOF
+	}
+	args := make([]string, n)
+	for i := range args {
+		if _, err := r.ReadString('\n'); err != nil {
+			return nil, err
+		}
+		arg, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		args[i] = strings.TrimRight(arg, "\r\n")
+	}
+	return args, nil
+}
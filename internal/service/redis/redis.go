@@ -1,101 +1,276 @@
 package redis
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-redis/redis"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"agregator/api/internal/interfaces"
+	"agregator/api/internal/pkg/config"
+	"agregator/api/internal/pkg/tracing"
 )
 
 type RedisCache struct {
 	client *redis.Client
+	logger interfaces.Logger
+
+	available  atomic.Bool // false — Redis недоступен, работаем без кэша
+	reconnects atomic.Int64
+
+	mu         sync.Mutex
+	buffer     map[string]int64 // Просмотры, накопленные во время недоступности Redis
+	bufferSize int
+	dropped    atomic.Int64
 }
 
-func New(addr string, password string) *RedisCache {
-	return &RedisCache{
+func New(addr string, password string, logger interfaces.Logger) *RedisCache {
+	r := &RedisCache{
 		client: redis.NewClient(&redis.Options{
 			Addr:     addr,
 			Password: password,
 			DB:       0,
+			// go-redis v6 не прерывает команды по контексту, поэтому время ограничиваем на уровне соединения
+			DialTimeout:  config.Duration("REDIS_DIAL_TIMEOUT", time.Second),
+			ReadTimeout:  config.Duration("REDIS_TIMEOUT", 500*time.Millisecond),
+			WriteTimeout: config.Duration("REDIS_TIMEOUT", 500*time.Millisecond),
 		}),
+		logger:     logger,
+		buffer:     make(map[string]int64),
+		bufferSize: config.Int("REDIS_VIEWS_BUFFER_SIZE", 10000),
+	}
+	r.available.Store(true)
+
+	// Проверяем соединение сразу, чтобы не начинать работу с недоступным кэшем
+	if err := r.client.Ping().Err(); err != nil {
+		r.markDown(context.Background(), err)
 	}
+	return r
+}
+
+// startSpan открывает дочерний спан на одну команду Redis
+func (r *RedisCache) startSpan(ctx context.Context, command string, key string) (context.Context, trace.Span) {
+	return tracing.Tracer().Start(ctx, "redis."+command,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemNameRedis,
+			semconv.DBOperationName(command),
+			attribute.String("db.redis.key", key),
+		),
+	)
+}
+
+// endSpan закрывает спан, отмечая ошибку (промах кэша ошибкой не считается)
+func endSpan(span trace.Span, err error) {
+	if err != nil && err != redis.Nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
 }
 
 // Set записывает ключ-значение в кэш с временем жизни 4 дня
-func (r *RedisCache) Set(key string, value interface{}, ttl time.Duration) error {
+func (r *RedisCache) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
 	// Устанавливаем ключ-значение в кэш
 
+	if !r.available.Load() {
+		return nil
+	}
+
 	jsonData, err := json.Marshal(value)
 	if err != nil {
 		return fmt.Errorf("failed to marshal data to JSON: %w", err)
 	}
 
-	err = r.client.Set(key, jsonData, ttl).Err()
+	spanCtx, span := r.startSpan(ctx, "SET", key)
+	err = r.client.WithContext(spanCtx).Set(key, jsonData, ttl).Err()
+	endSpan(span, err)
 	if err != nil {
-		return err
+		return r.check(ctx, err)
 	}
 
 	return nil
 }
 
-func (r *RedisCache) SetViews(id string, value int64, ttl time.Duration) error {
-	return r.Set("views:"+id, value, ttl)
+func (r *RedisCache) SetViews(ctx context.Context, id string, value int64, ttl time.Duration) error {
+	return r.Set(ctx, "views:"+id, value, ttl)
 }
 
-func (r *RedisCache) IncViews(id string) error {
+// IncViews увеличивает счетчик просмотров; при недоступном Redis просмотр копится в памяти
+func (r *RedisCache) IncViews(ctx context.Context, id string) error {
+	return r.AddViews(ctx, id, 1)
+}
 
-	exists, err := r.client.Exists("views:" + id).Result()
-	if err != nil {
-		return fmt.Errorf("failed to check Redis key existence: %w", err)
+// AddViews добавляет n просмотров к еще не сброшенным в БД (например, возвращает их после неудачного сброса)
+func (r *RedisCache) AddViews(ctx context.Context, id string, n int64) error {
+	if !r.available.Load() {
+		r.bufferViews(id, n)
+		return nil
+	}
+
+	err := r.incrViews(ctx, id, n)
+	if isConnError(err) {
+		r.bufferViews(id, n)
+		return nil
+	}
+	return err
+}
+
+// PendingViews возвращает просмотры, еще не записанные в БД: из Redis (включая
+// переносимые сейчас) и из буфера в памяти
+func (r *RedisCache) PendingViews(ctx context.Context, ids []uint64) (map[uint64]int64, error) {
+	pending := make(map[uint64]int64, len(ids))
+	if len(ids) == 0 {
+		return pending, nil
 	}
 
-	if exists == 0 {
-		// Если ключа нет, инициализируем его значением из базы
-		return r.SetViews(id, 1, 24*time.Hour)
+	r.mu.Lock()
+	for _, id := range ids {
+		if n, ok := r.buffer[strconv.FormatUint(id, 10)]; ok {
+			pending[id] += n
+		}
+	}
+	r.mu.Unlock()
+
+	if !r.available.Load() {
+		return pending, nil
+	}
+
+	// Одной командой читаем и новые просмотры, и переносимые в БД, чтобы сброс
+	// между чтениями не учел их дважды или не потерял
+	keys := make([]string, 0, 2*len(ids))
+	for _, id := range ids {
+		keys = append(keys, "views:"+strconv.FormatUint(id, 10), flushingPrefix+strconv.FormatUint(id, 10))
+	}
+	spanCtx, span := r.startSpan(ctx, "MGET", "views:*")
+	vals, err := r.client.WithContext(spanCtx).MGet(keys...).Result()
+	endSpan(span, err)
+	if err != nil {
+		return pending, r.check(ctx, err)
+	}
+	for i, val := range vals {
+		str, ok := val.(string)
+		if !ok {
+			continue
+		}
+		if n, err := strconv.ParseInt(str, 10, 64); err == nil {
+			pending[ids[i/2]] += n
+		}
 	}
+	return pending, nil
+}
 
-	return r.client.Incr("views:" + id).Err()
+// incrViews атомарно увеличивает счетчик на n и продлевает его время жизни
+func (r *RedisCache) incrViews(ctx context.Context, id string, n int64) error {
+	spanCtx, span := r.startSpan(ctx, "INCRBY", "views:"+id)
+	_, err := r.client.WithContext(spanCtx).TxPipelined(func(pipe redis.Pipeliner) error {
+		pipe.IncrBy("views:"+id, n)
+		pipe.Expire("views:"+id, viewsTTL)
+		return nil
+	})
+	endSpan(span, err)
+	return r.check(ctx, err)
 }
 
-func (r *RedisCache) GetAllViews() (map[int64]int64, error) {
-	keys, err := r.client.Keys("views:*").Result()
+// flushingPrefix — ключи просмотров, которые переносятся в БД прямо сейчас. Они
+// удаляются только после успешной записи в БД, так что просмотр в любой момент
+// лежит либо в Redis (views:<id> или views:flushing:<id>), либо уже в БД.
+const flushingPrefix = "views:flushing:"
+
+// takeViewsScript атомарно переносит views:<id> в views:flushing:<id>, прибавляя к
+// оставшемуся там после неудачного сброса, и возвращает итог для записи в БД
+var takeViewsScript = redis.NewScript(`
+local n = redis.call('GET', KEYS[1])
+if n then
+    redis.call('INCRBY', KEYS[2], n)
+    redis.call('DEL', KEYS[1])
+end
+redis.call('EXPIRE', KEYS[2], ARGV[1])
+return redis.call('GET', KEYS[2])`)
+
+// TakeViews готовит к сбросу в БД все накопленные просмотры. После записи в БД
+// каждую группу нужно подтвердить через AckViews; неподтвержденные вернутся при следующем вызове.
+func (r *RedisCache) TakeViews(ctx context.Context) (map[int64]int64, error) {
+	// Пока Redis недоступен, просмотры лежат в буфере и будут перенесены после восстановления
+	if !r.available.Load() {
+		return map[int64]int64{}, nil
+	}
+
+	spanCtx, span := r.startSpan(ctx, "KEYS", "views:*")
+	keys, err := r.client.WithContext(spanCtx).Keys("views:*").Result()
+	endSpan(span, err)
 	if err != nil {
-		return nil, err
+		return nil, r.check(ctx, err)
 	}
 
 	results := make(map[int64]int64)
 	for _, key := range keys {
-		val, err := r.client.Get(key).Result()
+		id := strings.TrimPrefix(strings.TrimPrefix(key, flushingPrefix), "views:")
+		key_int64, err := strconv.ParseInt(id, 10, 64)
 		if err != nil {
 			continue
 		}
-		key_int64, err := strconv.ParseInt(key[7:], 10, 64)
-		if err != nil {
+		if _, ok := results[key_int64]; ok {
 			continue
 		}
-		val_int64, err := strconv.ParseInt(val, 10, 64)
+
+		spanCtx, span = r.startSpan(ctx, "EVALSHA", flushingPrefix+id)
+		val, err := takeViewsScript.Run(r.client.WithContext(spanCtx), []string{"views:" + id, flushingPrefix + id}, int64(viewsTTL/time.Second)).Result()
+		endSpan(span, err)
 		if err != nil {
+			if isConnError(err) {
+				return results, r.check(ctx, err)
+			}
 			continue
 		}
-		results[key_int64] = val_int64
-
-		//set value to 0
-		err = r.client.Del(key).Err()
-		if err != nil {
+		str, ok := val.(string)
+		if !ok {
+			continue
+		}
+		val_int64, err := strconv.ParseInt(str, 10, 64)
+		if err != nil || val_int64 == 0 {
 			continue
 		}
+		results[key_int64] = val_int64
 	}
 	return results, nil
 }
 
-func (r *RedisCache) GetJSON(key string, dest interface{}) (bool, error) {
+// AckViews подтверждает, что просмотры группы, полученные из TakeViews, записаны в БД
+func (r *RedisCache) AckViews(ctx context.Context, id int64) error {
+	key := flushingPrefix + strconv.FormatInt(id, 10)
+	spanCtx, span := r.startSpan(ctx, "DEL", key)
+	err := r.client.WithContext(spanCtx).Del(key).Err()
+	endSpan(span, err)
+	return r.check(ctx, err)
+}
+
+func (r *RedisCache) GetJSON(ctx context.Context, key string, dest interface{}) (bool, error) {
+	// Без Redis работаем как при промахе кэша, не засоряя логи ошибками
+	if !r.available.Load() {
+		return false, nil
+	}
+
 	// Получаем JSON-строку из Redis
-	val, err := r.client.Get(key).Result()
+	spanCtx, span := r.startSpan(ctx, "GET", key)
+	val, err := r.client.WithContext(spanCtx).Get(key).Result()
+	endSpan(span, err)
 	if err == redis.Nil {
 		// Ключ не найден в кэше
 		return false, nil
+	} else if isConnError(err) {
+		r.markDown(ctx, err)
+		return false, nil
 	} else if err != nil {
 		// Произошла другая ошибка Redis
 		return false, fmt.Errorf("failed to get key '%s' from Redis: %w", key, err)
@@ -110,3 +285,38 @@ func (r *RedisCache) GetJSON(key string, dest interface{}) (bool, error) {
 	// Успешно найдено и демаршалировано
 	return true, nil
 }
+
+// MGetJSON получает несколько ключей одной командой MGET. Для ключей, которых нет
+// в кэше (или если Redis недоступен), в результате стоит nil.
+func (r *RedisCache) MGetJSON(ctx context.Context, keys []string) ([]json.RawMessage, error) {
+	values := make([]json.RawMessage, len(keys))
+	if !r.available.Load() || len(keys) == 0 {
+		return values, nil
+	}
+
+	spanCtx, span := r.startSpan(ctx, "MGET", keys[0])
+	span.SetAttributes(attribute.Int("db.redis.keys", len(keys)))
+	vals, err := r.client.WithContext(spanCtx).MGet(keys...).Result()
+	endSpan(span, err)
+	if isConnError(err) {
+		r.markDown(ctx, err)
+		return values, nil
+	} else if err != nil {
+		return values, fmt.Errorf("failed to get keys from Redis: %w", err)
+	}
+
+	for i, val := range vals {
+		if str, ok := val.(string); ok {
+			values[i] = json.RawMessage(str)
+		}
+	}
+	return values, nil
+}
+
+// Ping проверяет доступность Redis
+func (r *RedisCache) Ping(ctx context.Context) error {
+	ctx, span := r.startSpan(ctx, "PING", "")
+	err := r.client.WithContext(ctx).Ping().Err()
+	endSpan(span, err)
+	return err
+}
@@ -0,0 +1,103 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"agregator/api/internal/interfaces"
+	"agregator/api/internal/service/db"
+)
+
+// ProblemContentType — тип содержимого ответов с ошибкой по RFC 7807
+const ProblemContentType = "application/problem+json"
+
+// Problem — тело ответа с ошибкой по RFC 7807
+type Problem struct {
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Status    int    `json:"status"`
+	Detail    string `json:"detail,omitempty"`
+	Instance  string `json:"instance,omitempty"`
+	RequestID string `json:"requestId,omitempty"`
+
+	InvalidParams []FieldError `json:"invalidParams,omitempty"` // Ошибки по отдельным параметрам запроса
+}
+
+// FieldError описывает ошибку одного параметра запроса
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError — ошибка проверки параметров с подробностями по полям.
+// Считается db.ErrInvalidInput и отдается со статусом 400.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	parts := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		parts = append(parts, f.Field+" "+f.Message)
+	}
+	return "invalid parameters: " + strings.Join(parts, "; ")
+}
+
+func (e *ValidationError) Unwrap() error {
+	return db.ErrInvalidInput
+}
+
+// Errors превращает ошибку, добавленную обработчиком через c.Error, в ответ problem+json.
+// Статус выбирается по типу ошибки; для 5xx клиенту не отдаются детали,
+// а сама ошибка пишется в лог.
+func Errors(logger interfaces.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+
+		err := c.Errors.Last().Err
+		status := StatusFor(err)
+		problem := Problem{
+			Type:      "about:blank",
+			Title:     http.StatusText(status),
+			Status:    status,
+			Instance:  c.Request.URL.Path,
+			RequestID: c.Writer.Header().Get(RequestIDHeader),
+		}
+		var verr *ValidationError
+		if errors.As(err, &verr) {
+			problem.Detail = "request parameters are invalid"
+			problem.InvalidParams = verr.Fields
+		} else if status < 500 {
+			problem.Detail = err.Error()
+		} else {
+			logger.ErrorContext(c.Request.Context(), "Request failed", "status", status, "error", err.Error())
+		}
+
+		c.Header("Content-Type", ProblemContentType)
+		c.AbortWithStatusJSON(status, problem)
+	}
+}
+
+// StatusFor подбирает HTTP-статус для ошибки сервиса
+func StatusFor(err error) int {
+	switch {
+	case errors.Is(err, db.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, db.ErrInvalidInput):
+		return http.StatusBadRequest
+	case errors.Is(err, db.ErrTimeout), errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout
+	case errors.Is(err, db.ErrUnavailable), errors.Is(err, context.Canceled):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	mrand "math/rand/v2"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"agregator/api/internal/interfaces"
+	"agregator/api/internal/pkg/logging"
+)
+
+// RequestIDHeader — заголовок, в котором принимается и возвращается идентификатор запроса
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength ограничивает длину входящего идентификатора, чтобы клиент не раздувал логи
+const maxRequestIDLength = 128
+
+// RequestID берет X-Request-ID из запроса или генерирует новый, кладет его
+// в контекст запроса (для логов сервисов) и возвращает клиенту.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if id == "" || len(id) > maxRequestIDLength {
+			id = newRequestID()
+		}
+		c.Request = c.Request.WithContext(logging.WithRequestID(c.Request.Context(), id))
+		c.Header(RequestIDHeader, id)
+		c.Next()
+	}
+}
+
+func newRequestID() string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return fmt.Sprintf("%d", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(buf)
+}
+
+// AccessLog пишет одну структурированную запись на каждый запрос через interfaces.Logger.
+// Успешные ответы логируются с вероятностью sampleRate (0..1), ответы 4xx/5xx — всегда.
+func AccessLog(logger interfaces.Logger, sampleRate float64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		status := c.Writer.Status()
+		if status < 400 && sampleRate < 1 && mrand.Float64() >= sampleRate {
+			return
+		}
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		args := []any{
+			"method", c.Request.Method,
+			"route", route,
+			"path", c.Request.URL.Path,
+			"query", c.Request.URL.RawQuery,
+			"status", status,
+			"latency_ms", time.Since(start).Milliseconds(),
+			"bytes", c.Writer.Size(),
+			"client_ip", c.ClientIP(),
+			"user_agent", c.Request.UserAgent(),
+		}
+
+		ctx := c.Request.Context()
+		switch {
+		case status >= 500:
+			logger.ErrorContext(ctx, "HTTP request", args...)
+		case status >= 400:
+			logger.WarnContext(ctx, "HTTP request", args...)
+		default:
+			logger.InfoContext(ctx, "HTTP request", args...)
+		}
+	}
+}
+
+// Recovery перехватывает панику в обработчике и логирует ее через interfaces.Logger
+func Recovery(logger interfaces.Logger) gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(io.Discard, func(c *gin.Context, err any) {
+		logger.ErrorContext(c.Request.Context(), "Panic in handler", "error", fmt.Sprint(err), "path", c.Request.URL.Path)
+		// Ответ формирует middleware Errors
+		c.Error(fmt.Errorf("panic: %v", err))
+		c.Abort()
+	})
+}
@@ -2,37 +2,73 @@ package rest
 
 import (
 	"context"
-	"log"
 	"os"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gin-gonic/gin"
 
 	"agregator/api/internal/interfaces"
 	model "agregator/api/internal/model/db"
+	"agregator/api/internal/pkg/config"
+	"agregator/api/internal/service/classifier"
 	"agregator/api/internal/service/db"
+	"agregator/api/internal/service/embedder"
 	"agregator/api/internal/service/redis"
 )
 
 type API struct {
-	db     *db.DB
-	cache  *redis.RedisCache
-	logger interfaces.Logger
+	db         *db.DB
+	cache      *redis.RedisCache
+	embedder   interfaces.Embedder // nil, если семантический поиск не настроен
+	classifier interfaces.Classifier
+	logger     interfaces.Logger
+
+	lastViewsFlush atomic.Int64  // Время (unix nano) последнего успешного сброса просмотров в БД
+	lastGoodTTL    time.Duration // Время жизни снимков для работы без БД
+	snapshotSaved  sync.Map      // Ключ снимка -> время последней записи (time.Time)
+	snapshotPruned atomic.Int64  // Время (unix nano) последней очистки snapshotSaved
+	archiveTTL     time.Duration // Время жизни кэша списков за прошедшие дни
+	openapi        []byte        // Документ для /api/openapi.json
 }
 
 func New(logger interfaces.Logger) (*API, error) {
+	if err := registerValidators(); err != nil {
+		return nil, err
+	}
+	spec, err := OpenAPI()
+	if err != nil {
+		return nil, err
+	}
+	classifier, err := classifier.New()
+	if err != nil {
+		return nil, err
+	}
 	db, err := db.New(logger)
 	api := &API{
-		db:     db,
-		cache:  redis.New(os.Getenv("REDIS_ADDR"), os.Getenv("REDIS_PASSWORD")),
-		logger: logger,
+		db:         db,
+		cache:      redis.New(os.Getenv("REDIS_ADDR"), os.Getenv("REDIS_PASSWORD"), logger),
+		embedder:   embedder.New(logger),
+		classifier: classifier,
+		logger:     logger,
+
+		lastGoodTTL: config.Duration("CACHE_LAST_GOOD_TTL", 7*24*time.Hour),
+		archiveTTL:  config.Duration("CACHE_TTL_ARCHIVE", 24*time.Hour),
+		openapi:     spec,
 	}
 	if err != nil {
 		return nil, err
 	}
+	// Не стартуем на схеме, под которую не написаны запросы
+	if err := db.CheckSchema(context.Background()); err != nil {
+		return nil, err
+	}
 	go api.updateViews(context.Background())
+	go api.linkStories(context.Background())
+	go api.classifyGroups(context.Background())
 	return api, nil
 }
 
@@ -49,28 +85,36 @@ func (a *API) updateViews(ctx context.Context) {
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
-			views, err := a.cache.GetAllViews()
+			views, err := a.cache.TakeViews(ctx)
 			if err != nil {
-				a.logger.Error("Error getting views", "error", err.Error())
+				a.logger.ErrorContext(ctx, "Error getting views", "error", err.Error())
 				continue
 			}
+			flushed := true
 			for key, value := range views {
-				err := a.db.UpdateViews(uint64(key), uint64(value))
-				if err != nil {
-					a.logger.Error("Error updating views", "error", err.Error())
+				// Не подтвержденные в Redis просмотры остаются там и повторяются при следующем сбросе
+				if err := a.db.UpdateViews(ctx, uint64(key), uint64(value)); err != nil {
+					a.logger.ErrorContext(ctx, "Error updating views", "error", err.Error())
+					flushed = false
+					continue
 				}
+				if err := a.cache.AckViews(ctx, key); err != nil {
+					a.logger.ErrorContext(ctx, "Error acknowledging views", "id", key, "error", err.Error())
+					flushed = false
+				}
+			}
+			if flushed {
+				a.lastViewsFlush.Store(time.Now().UnixNano())
 			}
 		}
 	}
 }
 
 func (a *API) GetMax(c *gin.Context) {
-	max, err := a.db.GetLastIndex()
+	ctx := c.Request.Context()
+	max, err := a.db.GetLastIndex(ctx)
 	if err != nil {
-		a.logger.Error("Error getting max", "error", err.Error())
-		c.JSON(500, gin.H{
-			"error": err.Error(),
-		})
+		c.Error(err)
 		return
 	}
 	c.JSON(200, gin.H{
@@ -79,213 +123,179 @@ func (a *API) GetMax(c *gin.Context) {
 }
 
 func (a *API) Get(c *gin.Context) {
-	date_str := c.DefaultQuery("date", "")
-	limit_str := c.DefaultQuery("limit", "15")
-	search_str := c.DefaultQuery("q", "")
-	search_elements := strings.Split(search_str, ",")
-
-	if len(search_elements) > 0 {
-		for i, s := range search_elements {
-			search_elements[i] = strings.TrimSpace(s)
-		}
+	ctx := c.Request.Context()
+	var query listQuery
+	if err := bindQuery(c, &query); err != nil {
+		c.Error(err)
+		return
 	}
 
-	limit, err := strconv.ParseUint(limit_str, 10, 64)
-	if err != nil {
-		limit = 15
+	search_elements := strings.Split(query.Query, ",")
+	for i, s := range search_elements {
+		search_elements[i] = strings.TrimSpace(s)
 	}
 
-	var date time.Time
-	if date_str == "" {
-		date = time.Now()
-	} else {
-		date, err = time.Parse(time.RFC3339, date_str)
-		if err != nil {
-			date = time.Now()
+	date, date_key := query.before()
+
+	filter := query.filter()
+	var items []model.List
+	var err error
+	if query.Query == "" {
+		// Ленту без поиска не кэшируем; для первой страницы храним снимок на случай недоступности БД
+		fetch := func(ctx context.Context) ([]model.List, error) {
+			return a.db.Get(ctx, date, query.Limit, filter)
 		}
-	}
-	if len(search_elements) == 0 {
-		items, err := a.db.Get(date, limit)
-		if err != nil {
-			c.JSON(500, gin.H{
-				"error": err.Error(),
-			})
-			return
+		if date_key == "" && filter.Key() == "" {
+			items, err = cachedSnapshot(a, c, "clusters:all:"+strconv.FormatUint(query.Limit, 10), 0, fetch)
+		} else {
+			items, err = fetch(ctx)
 		}
-		c.JSON(200, gin.H{"items": items})
 	} else {
-		items, err := a.db.Get(date, limit, search_elements...)
-		if err != nil {
-			a.logger.Error("Error getting items", "error", err.Error())
-			c.JSON(500, gin.H{
-				"error": err.Error(),
-			})
-			return
-		}
-		c.JSON(200, gin.H{"items": items})
+		items, err = a.db.Get(ctx, date, query.Limit, filter, search_elements...)
 	}
-}
-
-func (a *API) GetTop(c *gin.Context) {
-	limit_str := c.DefaultQuery("limit", "15")
-	limit, err := strconv.ParseUint(limit_str, 10, 64)
 	if err != nil {
-		limit = 15
+		c.Error(err)
+		return
 	}
+	c.JSON(200, gin.H{"items": query.fieldset().project(a.withListViews(c.Request.Context(), items))})
+}
 
-	var items []model.List
-	ok, err := a.cache.GetJSON("clusters:top", &items)
-	if err == nil && ok {
-		c.JSON(200, gin.H{"items": items})
+func (a *API) GetTop(c *gin.Context) {
+	var query topQuery
+	if err := bindQuery(c, &query); err != nil {
+		c.Error(err)
 		return
-	} else if err != nil {
-		a.logger.Error("Error getting items from cache", "error", err.Error())
 	}
 
-	items, err = a.db.GetTopGroupsByFeedCount(limit)
-	if err != nil {
-		a.logger.Error("Error getting items from database", "error", err.Error())
-		c.JSON(500, gin.H{
-			"error": err.Error(),
-		})
+	filter := query.filter()
+	if err := query.apply(&filter); err != nil {
+		c.Error(err)
 		return
 	}
-	c.JSON(200, gin.H{"items": items})
-	err = a.cache.Set("clusters:top", items, 10*time.Minute)
+	items, err := a.cachedList(c, "clusters:top:"+strconv.FormatUint(query.Limit, 10)+":"+filter.Key(), query.timeRange, filter, func(ctx context.Context) ([]model.List, error) {
+		return a.db.GetTopGroupsByFeedCount(ctx, query.Limit, filter)
+	})
 	if err != nil {
-		log.Println(err)
+		c.Error(err)
+		return
 	}
+	c.JSON(200, gin.H{"items": query.fieldset().project(a.withListViews(c.Request.Context(), items))})
 }
 
-func (a *API) GetRT(c *gin.Context) {
-	limit_str := c.DefaultQuery("limit", "15")
-	limit, err := strconv.ParseUint(limit_str, 10, 64)
-	if err != nil {
-		limit = 15
+// listTTL возвращает время жизни кэша списка: выборки за прошедшие дни храним дольше
+func (a *API) listTTL(r timeRange) time.Duration {
+	if r.archived() {
+		return a.archiveTTL
 	}
-	is_rt_str := c.DefaultQuery("rt", "true")
-	is_rt := strings.ToLower(is_rt_str) == "true"
+	return 10 * time.Minute
+}
 
-	var items []model.List
-	if is_rt {
-		ok, err := a.cache.GetJSON("clusters:rt", &items)
-		if err == nil && ok {
-			c.JSON(200, gin.H{"items": items})
-			return
-		} else if err != nil {
-			a.logger.Error("Error getting items from cache", "error", err.Error())
-		}
-	} else {
-		ok, err := a.cache.GetJSON("clusters:not_rt", &items)
-		if err == nil && ok {
-			c.JSON(200, gin.H{"items": items})
-			return
-		} else if err != nil {
-			a.logger.Error("Error getting items from cache", "error", err.Error())
-		}
+// cachedList кэширует ленту top/rt; снимок lastgood хранится только для ленты
+// по умолчанию — без диапазона времени и фильтров
+func (a *API) cachedList(c *gin.Context, key string, r timeRange, filter db.ListFilter, fetch func(context.Context) ([]model.List, error)) ([]model.List, error) {
+	if r == (timeRange{}) && filter.Key() == "" {
+		return cachedSnapshot(a, c, key, a.listTTL(r), fetch)
 	}
+	return cached(a, c, key, a.listTTL(r), fetch)
+}
 
-	items, err = a.db.GetRTGroups(limit, is_rt)
-	if err != nil {
-		a.logger.Error("Error getting items from database", "error", err.Error())
-		c.JSON(500, gin.H{
-			"error": err.Error(),
-		})
+func (a *API) GetRT(c *gin.Context) {
+	var query rtQuery
+	if err := bindQuery(c, &query); err != nil {
+		c.Error(err)
 		return
 	}
-	c.JSON(200, gin.H{"items": items})
-	if is_rt {
-		err = a.cache.Set("clusters:rt", items, 10*time.Minute)
-	} else {
-		err = a.cache.Set("clusters:not_rt", items, 10*time.Minute)
 
+	key := "clusters:not_rt:"
+	if query.RT {
+		key = "clusters:rt:"
+	}
+	filter := query.filter()
+	if err := query.apply(&filter); err != nil {
+		c.Error(err)
+		return
 	}
+	items, err := a.cachedList(c, key+strconv.FormatUint(query.Limit, 10)+":"+filter.Key(), query.timeRange, filter, func(ctx context.Context) ([]model.List, error) {
+		return a.db.GetRTGroups(ctx, query.Limit, query.RT, filter)
+	})
 	if err != nil {
-		a.logger.Error("Error setting items in cache", "error", err.Error())
+		c.Error(err)
+		return
 	}
+	c.JSON(200, gin.H{"items": query.fieldset().project(a.withListViews(c.Request.Context(), items))})
 }
 
 func (a *API) GetByID(c *gin.Context) {
+	ctx := c.Request.Context()
 	c.Header("Access-Control-Allow-Origin", "*")
 	c.Header("Access-Control-Allow-Methods", "POST, GET, OPTIONS")
 	c.Header("Access-Control-Allow-Headers", "Content-Type")
-	id_str := c.Param("id")
-	id, err := strconv.ParseUint(id_str, 10, 64)
-	if err != nil {
-		a.logger.Error("Error getting id", "error", err.Error())
-		c.JSON(400, gin.H{
-			"error": err.Error(),
-		})
+	var param idParam
+	if err := bindURI(c, &param); err != nil {
+		c.Error(err)
 		return
 	}
-
-	var item model.News
-	ok, err := a.cache.GetJSON("clusters:"+id_str, &item)
-	if err == nil && ok {
-		c.JSON(200, item)
+	var query newsQuery
+	if err := bindQuery(c, &query); err != nil {
+		c.Error(err)
 		return
-	} else if err != nil {
-		a.logger.Error("Error getting data from cache", "error", err.Error())
-		log.Println(err)
 	}
+	id_str := strconv.FormatUint(param.ID, 10)
+	fields := query.fieldset()
+	columns := fields.newsColumns()
+	columns.SourcesLimit = int(query.SourcesLimit)
 
-	item, err = a.db.GetByID(id)
+	// Варианты без полных текстов кэшируются отдельно от полного ответа;
+	// снимок на случай недоступности БД храним только для полного
+	fetch := func(ctx context.Context) (model.News, error) {
+		return a.db.GetByID(ctx, param.ID, columns)
+	}
+	key := "clusters:" + id_str + columns.Key()
+	var item model.News
+	var err error
+	if columns.Key() == "" {
+		item, err = cachedSnapshot(a, c, key, 1*time.Hour, fetch)
+	} else {
+		item, err = cached(a, c, key, 1*time.Hour, fetch)
+	}
 	if err != nil {
-		a.logger.Error("Error getting data from database", "error", err.Error())
-		c.JSON(500, gin.H{
-			"error": err.Error(),
-		})
+		c.Error(err)
 		return
 	}
 
-	c.JSON(200, item)
-	err = a.cache.Set("clusters:"+id_str, item, 1*time.Hour)
-	if err != nil {
-		a.logger.Error("Error setting data in cache", "error", err.Error())
-	}
+	c.JSON(200, fields.project(a.withNewsViews(ctx, item)))
+	// Контекст запроса отменяется после ответа, поэтому отвязываемся от отмены, сохраняя трейс
+	viewsCtx := context.WithoutCancel(ctx)
 	go func() {
-		err := a.cache.IncViews(id_str)
+		err := a.cache.IncViews(viewsCtx, id_str)
 		if err != nil {
-			a.logger.Error("Error getting data from cache", "error", err.Error())
+			a.logger.ErrorContext(viewsCtx, "Error incrementing views in cache", "error", err.Error())
 		}
 	}()
 }
 
 func (a *API) GetSimilar(c *gin.Context) {
-	id_str := c.Param("id")
-	id, err := strconv.ParseUint(id_str, 10, 64)
-	if err != nil {
-		a.logger.Error("Error getting id", "error", err.Error())
-		c.JSON(400, gin.H{
-			"error": err.Error(),
-		})
+	var param idParam
+	if err := bindURI(c, &param); err != nil {
+		c.Error(err)
 		return
 	}
-	limit_str := c.DefaultQuery("limit", "10")
-	limit, err := strconv.ParseUint(limit_str, 10, 64)
-	if err != nil {
-		limit = 10
-	}
-	var items []model.List
-	ok, err := a.cache.GetJSON("clusters:similar:"+id_str, &items)
-	if err == nil && ok {
-		c.JSON(200, gin.H{"items": items})
+	var query similarQuery
+	if err := bindQuery(c, &query); err != nil {
+		c.Error(err)
 		return
-	} else if err != nil {
-		a.logger.Error("Error getting items from cache", "error", err.Error())
 	}
+	id_str := strconv.FormatUint(param.ID, 10)
 
-	items, err = a.db.GetSimilarGroups(id, limit)
+	filter := query.filter()
+	opts := query.options()
+	key := "clusters:similar:" + id_str + ":" + strconv.FormatUint(query.Limit, 10) + ":" + filter.Key() + ":" + opts.Key()
+	items, err := cached(a, c, key, 1*time.Hour, func(ctx context.Context) ([]model.List, error) {
+		return a.db.GetSimilarGroups(ctx, param.ID, query.Limit, filter, opts)
+	})
 	if err != nil {
-		a.logger.Error("Error getting items from database", "error", err.Error())
-		c.JSON(500, gin.H{
-			"error": err.Error(),
-		})
+		c.Error(err)
 		return
 	}
-	c.JSON(200, gin.H{"items": items})
-	err = a.cache.Set("clusters:similar:"+id_str, items, 1*time.Hour)
-	if err != nil {
-		a.logger.Error("Error setting items in cache", "error", err.Error())
-	}
+	c.JSON(200, gin.H{"items": query.fieldset().project(a.withListViews(c.Request.Context(), items))})
 }
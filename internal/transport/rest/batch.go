@@ -0,0 +1,158 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+
+	model "agregator/api/internal/model/db"
+	"agregator/api/internal/transport/middleware"
+)
+
+// batchRequest — тело POST /get и результат разбора GET /get?ids=
+type batchRequest struct {
+	IDs    []uint64 `json:"ids" binding:"required,min=1,maxlimit=batch,dive,min=1"`
+	View   string   `json:"view" binding:"oneof=list news"` // list — карточки model.List, news — model.News
+	Fields string   `json:"fields" binding:"max=1000"`      // Как в списках или в /get/:id, в зависимости от view
+}
+
+type batchQuery struct {
+	IDs    []string `form:"ids"` // 1,2,3 или повтор параметра
+	View   string   `form:"view,default=list"`
+	Fields string   `form:"fields"`
+}
+
+// GetBatch возвращает группы по списку идентификаторов: GET /get?ids=1,2,3
+func (a *API) GetBatch(c *gin.Context) {
+	var query batchQuery
+	if err := bindQuery(c, &query); err != nil {
+		c.Error(err)
+		return
+	}
+	req := batchRequest{View: query.View, Fields: query.Fields}
+	for _, v := range splitValues(query.IDs) {
+		id, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			c.Error(&middleware.ValidationError{Fields: []middleware.FieldError{{Field: "ids", Message: "must be a comma-separated list of positive integers"}}})
+			return
+		}
+		req.IDs = append(req.IDs, id)
+	}
+	if err := bindingError(c, binding.Validator.ValidateStruct(&req)); err != nil {
+		c.Error(err)
+		return
+	}
+	a.batch(c, req)
+}
+
+// PostBatch — вариант GetBatch для длинных списков: POST /get {"ids": [...], "view": "news"}
+func (a *API) PostBatch(c *gin.Context) {
+	req := batchRequest{View: "list"}
+	if err := bindingError(c, c.ShouldBindJSON(&req)); err != nil {
+		c.Error(err)
+		return
+	}
+	a.batch(c, req)
+}
+
+func (a *API) batch(c *gin.Context, req batchRequest) {
+	fields, unknown := parseFields(req.Fields, req.View)
+	if unknown != "" {
+		c.Error(&middleware.ValidationError{Fields: []middleware.FieldError{{Field: "fields", Message: "has unknown field " + strconv.Quote(unknown)}}})
+		return
+	}
+
+	ids := uniqueIDs(req.IDs)
+	var err error
+	var body gin.H
+	if req.View == "news" {
+		columns := fields.newsColumns()
+		fetch := func(ctx context.Context, ids []uint64) ([]model.News, error) {
+			return a.db.GetByIDs(ctx, ids, columns)
+		}
+		var items []model.News
+		var missing []uint64
+		// Ключи совпадают с /get/:id, поэтому кэш общий
+		items, missing, err = batchCached(a, c, ids, "clusters:", columns.Key(), 1*time.Hour, fetch, func(n model.News) uint64 { return n.ID })
+		body = gin.H{"items": fields.project(a.withNewsItemsViews(c.Request.Context(), items)), "missing": missing}
+	} else {
+		var items []model.List
+		var missing []uint64
+		items, missing, err = batchCached(a, c, ids, "clusters:item:", "", 10*time.Minute, a.db.GetListByIDs, func(l model.List) uint64 { return l.ID })
+		body = gin.H{"items": fields.project(a.withListViews(c.Request.Context(), items)), "missing": missing}
+	}
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(200, body)
+}
+
+// batchCached достает элементы из кэша одной командой MGET, а промахи — одним запросом к БД,
+// после чего кладет их в кэш. Ключ элемента — prefix + id + suffix.
+// Возвращает элементы в порядке ids и идентификаторы, которых нет в БД.
+func batchCached[T any](a *API, c *gin.Context, ids []uint64, prefix, suffix string, ttl time.Duration,
+	fetch func(context.Context, []uint64) ([]T, error), idOf func(T) uint64) ([]T, []uint64, error) {
+	ctx := c.Request.Context()
+
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = prefix + strconv.FormatUint(id, 10) + suffix
+	}
+	raw, err := a.cache.MGetJSON(ctx, keys)
+	if err != nil {
+		a.logger.ErrorContext(ctx, "Error getting data from cache", "error", err.Error())
+	}
+
+	found := make(map[uint64]T, len(ids))
+	var misses []uint64
+	for i, id := range ids {
+		var item T
+		if raw[i] != nil && json.Unmarshal(raw[i], &item) == nil {
+			found[id] = item
+			continue
+		}
+		misses = append(misses, id)
+	}
+
+	if len(misses) > 0 {
+		fetched, err := fetch(ctx, misses)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, item := range fetched {
+			id := idOf(item)
+			found[id] = item
+			if err := a.cache.Set(ctx, prefix+strconv.FormatUint(id, 10)+suffix, item, ttl); err != nil {
+				a.logger.ErrorContext(ctx, "Error setting data in cache", "error", err.Error())
+			}
+		}
+	}
+
+	items := make([]T, 0, len(found))
+	missing := []uint64{}
+	for _, id := range ids {
+		if item, ok := found[id]; ok {
+			items = append(items, item)
+		} else {
+			missing = append(missing, id)
+		}
+	}
+	return items, missing, nil
+}
+
+// uniqueIDs убирает повторы, сохраняя порядок
+func uniqueIDs(ids []uint64) []uint64 {
+	out := make([]uint64, 0, len(ids))
+	for _, id := range ids {
+		if !slices.Contains(out, id) {
+			out = append(out, id)
+		}
+	}
+	return out
+}
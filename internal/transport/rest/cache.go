@@ -0,0 +1,114 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"agregator/api/internal/service/db"
+)
+
+// lastGoodPrefix — префикс ключей со снимками последних успешных ответов БД.
+// Снимки живут дольше обычного кэша и отдаются, пока база недоступна. Хранятся
+// только для первых страниц основных лент и полных новостей (см. cachedSnapshot),
+// чтобы число ключей не зависело от параметров, которые присылают клиенты.
+const lastGoodPrefix = "lastgood:"
+
+// snapshotInterval — как часто обновлять снимок одного ключа; без него лента
+// без кэша (ttl == 0) писала бы снимок на каждый запрос
+const snapshotInterval = time.Minute
+
+// StaleHeader выставляется в ответах, собранных из снимка вместо свежих данных
+const StaleHeader = "X-Stale"
+
+type snapshot[T any] struct {
+	SavedAt time.Time `json:"savedAt"`
+	Data    T         `json:"data"`
+}
+
+// cached возвращает данные из кэша по key, а при промахе — из fetch.
+// Свежий результат кладется в кэш на ttl (ttl == 0 — не кэшировать).
+func cached[T any](a *API, c *gin.Context, key string, ttl time.Duration, fetch func(context.Context) (T, error)) (T, error) {
+	return cachedWith(a, c, key, ttl, false, fetch)
+}
+
+// cachedSnapshot — cached, который дополнительно хранит снимок lastgood (не чаще
+// snapshotInterval). Если fetch завершился ошибкой, отдается снимок, а ответ
+// помечается устаревшим.
+func cachedSnapshot[T any](a *API, c *gin.Context, key string, ttl time.Duration, fetch func(context.Context) (T, error)) (T, error) {
+	return cachedWith(a, c, key, ttl, true, fetch)
+}
+
+func cachedWith[T any](a *API, c *gin.Context, key string, ttl time.Duration, withSnapshot bool, fetch func(context.Context) (T, error)) (T, error) {
+	ctx := c.Request.Context()
+
+	var data T
+	if ttl > 0 {
+		ok, err := a.cache.GetJSON(ctx, key, &data)
+		if err == nil && ok {
+			return data, nil
+		} else if err != nil {
+			a.logger.ErrorContext(ctx, "Error getting data from cache", "key", key, "error", err.Error())
+		}
+	}
+
+	data, err := fetch(ctx)
+	if err != nil {
+		if !withSnapshot || errors.Is(err, db.ErrNotFound) || errors.Is(err, db.ErrInvalidInput) {
+			return data, err
+		}
+		var snap snapshot[T]
+		ok, cacheErr := a.cache.GetJSON(ctx, lastGoodPrefix+key, &snap)
+		if cacheErr != nil {
+			a.logger.ErrorContext(ctx, "Error getting snapshot from cache", "key", key, "error", cacheErr.Error())
+		}
+		if !ok {
+			return data, err
+		}
+		a.logger.WarnContext(ctx, "Serving stale data", "key", key, "saved_at", snap.SavedAt, "error", err.Error())
+		c.Header(StaleHeader, "true")
+		c.Header("Age", strconv.FormatInt(int64(time.Since(snap.SavedAt).Seconds()), 10))
+		return snap.Data, nil
+	}
+
+	if ttl > 0 {
+		if err := a.cache.Set(ctx, key, data, ttl); err != nil {
+			a.logger.ErrorContext(ctx, "Error setting data in cache", "key", key, "error", err.Error())
+		}
+	}
+	if withSnapshot && a.snapshotDue(key) {
+		if err := a.cache.Set(ctx, lastGoodPrefix+key, snapshot[T]{SavedAt: time.Now(), Data: data}, a.lastGoodTTL); err != nil {
+			a.logger.ErrorContext(ctx, "Error setting snapshot in cache", "key", key, "error", err.Error())
+		}
+	}
+	return data, nil
+}
+
+// snapshotDue сообщает, что снимок key пора обновить, и запоминает время обновления
+func (a *API) snapshotDue(key string) bool {
+	now := time.Now()
+	a.pruneSnapshotTimes(now)
+	if last, ok := a.snapshotSaved.Load(key); ok && now.Sub(last.(time.Time)) < snapshotInterval {
+		return false
+	}
+	a.snapshotSaved.Store(key, now)
+	return true
+}
+
+// pruneSnapshotTimes раз в snapshotInterval забывает устаревшие отметки, чтобы
+// снимки новостей не копили ключи в памяти бесконечно
+func (a *API) pruneSnapshotTimes(now time.Time) {
+	last := a.snapshotPruned.Load()
+	if now.UnixNano()-last < int64(snapshotInterval) || !a.snapshotPruned.CompareAndSwap(last, now.UnixNano()) {
+		return
+	}
+	a.snapshotSaved.Range(func(key, saved any) bool {
+		if now.Sub(saved.(time.Time)) >= snapshotInterval {
+			a.snapshotSaved.Delete(key)
+		}
+		return true
+	})
+}
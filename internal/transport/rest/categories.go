@@ -0,0 +1,78 @@
+package rest
+
+import (
+	"context"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	model "agregator/api/internal/model/db"
+	"agregator/api/internal/pkg/config"
+)
+
+type categoriesQuery struct {
+	filterQuery
+	Window string `form:"window,default=24h" binding:"window"`
+}
+
+// GetCategories возвращает число групп по рубрикам за период window
+func (a *API) GetCategories(c *gin.Context) {
+	var query categoriesQuery
+	if err := bindQuery(c, &query); err != nil {
+		c.Error(err)
+		return
+	}
+	window, _ := parseWindow(query.Window)
+	filter := query.filter()
+
+	items, err := cached(a, c, "categories:"+window.String()+":"+filter.Key(), 10*time.Minute, func(ctx context.Context) ([]model.CategoryCount, error) {
+		return a.db.GetCategoryCounts(ctx, window, filter)
+	})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(200, gin.H{"items": items})
+}
+
+// classifyGroups периодически рубрицирует новые группы; CATEGORY_INTERVAL=0 отключает рубрикацию
+func (a *API) classifyGroups(ctx context.Context) {
+	interval := config.Duration("CATEGORY_INTERVAL", 5*time.Minute)
+	if interval <= 0 {
+		return
+	}
+	lookback := config.Duration("CATEGORY_LOOKBACK", 7*24*time.Hour)
+	batch := config.Int("CATEGORY_BATCH", 500)
+	timeout := config.Duration("CATEGORY_TIMEOUT", time.Minute)
+
+	ticker := time.NewTicker(interval)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			docs, err := a.db.GetUnclassified(ctx, time.Now().Add(-lookback), batch, timeout)
+			if err != nil {
+				a.logger.ErrorContext(ctx, "Error getting groups to classify", "error", err.Error())
+				continue
+			}
+			if len(docs) == 0 {
+				continue
+			}
+
+			categories := make(map[uint64]string, len(docs))
+			for _, doc := range docs {
+				category, err := a.classifier.Classify(ctx, doc.Titles, doc.Text)
+				if err != nil {
+					// Группа останется необработанной и попадет в следующий проход
+					a.logger.ErrorContext(ctx, "Error classifying group", "error", err.Error(), "id", doc.ID)
+					continue
+				}
+				categories[doc.ID] = category
+			}
+			if err := a.db.SetCategories(ctx, categories, timeout); err != nil {
+				a.logger.ErrorContext(ctx, "Error saving categories", "error", err.Error())
+			}
+		}
+	}
+}
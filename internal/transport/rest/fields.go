@@ -0,0 +1,269 @@
+package rest
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	model "agregator/api/internal/model/db"
+	"agregator/api/internal/service/db"
+)
+
+// fieldset — дерево запрошенных полей ответа по их JSON-именам.
+// nil означает «все поля» (и для ответа целиком, и для вложенного объекта).
+type fieldset map[string]fieldset
+
+// fieldPresets — именованные наборы полей; full — все поля
+var fieldPresets = map[string]map[string]string{
+	"list": {
+		"card": "id,date,title,enclosure,isRT,sourceName,viewsCount,category,storyId,score,source.displayName,source.logoUrl",
+		"full": "",
+	},
+	"news": {
+		"card": "id,title,date,enclosure,viewsCount,category,storyId,primaryFeedId," +
+			"sources.id,sources.title,sources.name,sources.link,sources.pubDate,sources.primary,sources.source.displayName,sources.source.logoUrl",
+		"full": "",
+	},
+	"sources": {
+		"card": "total,nextCursor,items.id,items.title,items.name,items.link,items.pubDate,items.primary,items.source.displayName,items.source.logoUrl",
+		"full": "",
+	},
+}
+
+// knownFields — допустимые поля для каждого вида ответа, собираются из JSON-тегов моделей
+var knownFields = map[string]fieldset{
+	"list":    jsonFields(reflect.TypeFor[model.List]()),
+	"news":    jsonFields(reflect.TypeFor[model.News]()),
+	"sources": jsonFields(reflect.TypeFor[model.SourcePage]()),
+}
+
+// jsonFields строит полное дерево полей типа по JSON-тегам
+func jsonFields(t reflect.Type) fieldset {
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || t == reflect.TypeFor[time.Time]() || t == reflect.TypeFor[model.NullString]() {
+		return nil
+	}
+	fields := make(fieldset)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if !f.IsExported() || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[name] = jsonFields(f.Type)
+	}
+	return fields
+}
+
+// parseFields разбирает список полей и пресетов вида "card,sources.full_text" для вида ответа kind.
+// Пустая строка и пресет full дают nil (все поля). Возвращает первое неизвестное поле.
+func parseFields(s, kind string) (fieldset, string) {
+	if s == "" {
+		return nil, ""
+	}
+	var names []string
+	for _, name := range strings.Split(s, ",") {
+		name = strings.TrimSpace(name)
+		if preset, ok := fieldPresets[kind][name]; ok {
+			if preset == "" {
+				return nil, ""
+			}
+			names = append(names, strings.Split(preset, ",")...)
+			continue
+		}
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+
+	fields := make(fieldset)
+	for _, name := range names {
+		if !knownFields[kind].has(name) {
+			return nil, name
+		}
+		fields.add(strings.Split(name, "."))
+	}
+	return fields, ""
+}
+
+func (f fieldset) has(name string) bool {
+	node := f
+	for _, part := range strings.Split(name, ".") {
+		child, ok := node[part]
+		if !ok {
+			return false
+		}
+		node = child
+	}
+	return true
+}
+
+func (f fieldset) add(path []string) {
+	child, ok := f[path[0]]
+	if len(path) == 1 {
+		// Поле целиком перекрывает ранее запрошенные вложенные поля
+		f[path[0]] = nil
+		return
+	}
+	if ok && child == nil {
+		return
+	}
+	if !ok {
+		child = make(fieldset)
+		f[path[0]] = child
+	}
+	child.add(path[1:])
+}
+
+// wants сообщает, будет ли поле (путь через точку) в ответе
+func (f fieldset) wants(name string) bool {
+	node := f
+	for _, part := range strings.Split(name, ".") {
+		if node == nil {
+			return true
+		}
+		child, ok := node[part]
+		if !ok {
+			return false
+		}
+		node = child
+	}
+	return true
+}
+
+// project оставляет в v только запрошенные поля. Структуры разбираются по JSON-тегам
+// и заменяются на map с исходными значениями полей, так что ответ сериализуется один раз.
+func (f fieldset) project(v any) any {
+	if f == nil {
+		return v
+	}
+	return f.projectValue(reflect.ValueOf(v))
+}
+
+func (f fieldset) projectValue(v reflect.Value) any {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	switch {
+	case v.Kind() == reflect.Slice:
+		if v.IsNil() {
+			return nil
+		}
+		out := make([]any, v.Len())
+		for i := range out {
+			out[i] = f.projectValue(v.Index(i))
+		}
+		return out
+	case v.Kind() == reflect.Struct && jsonFields(v.Type()) != nil:
+		out := make(map[string]any, len(f))
+		f.projectStruct(v, out)
+		return out
+	default:
+		return v.Interface()
+	}
+}
+
+// projectStruct переносит запрошенные поля структуры в out; встроенные структуры
+// без JSON-имени раскрываются, как это делает encoding/json
+func (f fieldset) projectStruct(v reflect.Value, out map[string]any) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			f.projectStruct(v.Field(i), out)
+			continue
+		}
+		if !field.IsExported() || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		child, ok := f[name]
+		if !ok {
+			continue
+		}
+		value := v.Field(i)
+		if strings.Contains(opts, "omitempty") && isEmptyValue(value) {
+			continue
+		}
+		if child == nil {
+			out[name] = value.Interface()
+			continue
+		}
+		out[name] = child.projectValue(value)
+	}
+}
+
+// isEmptyValue повторяет правило omitempty из encoding/json
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Pointer, reflect.Interface:
+		return v.IsNil()
+	default:
+		return v.IsZero() && v.Kind() != reflect.Struct
+	}
+}
+
+// newsColumns определяет, какие тяжелые колонки новости нужно читать из БД.
+// Из БД исключаются только полные тексты; остальные поля fields лишь убирает из ответа.
+func (f fieldset) newsColumns() db.NewsColumns {
+	return db.NewsColumns{
+		Rewrite:    f.wants("rewrite"),
+		SourceText: f.wants("sources.full_text"),
+	}
+}
+
+// fieldNames перечисляет допустимые поля вида ответа для сообщений об ошибке
+func fieldNames(kind string) string {
+	names := make([]string, 0, len(fieldPresets[kind]))
+	for preset := range fieldPresets[kind] {
+		names = append(names, preset)
+	}
+	for name := range knownFields[kind] {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// listFields, newsFields и sourcesFields — параметр fields: поля ответа через запятую (вложенные через точку)
+// и пресеты card, full. Значение проверяется валидатором fields при разборе запроса.
+type listFields struct {
+	Fields string `form:"fields" json:"fields" binding:"max=1000,fields=list"`
+}
+
+func (q listFields) fieldset() fieldset {
+	f, _ := parseFields(q.Fields, "list")
+	return f
+}
+
+type newsFields struct {
+	Fields string `form:"fields" json:"fields" binding:"max=1000,fields=news"`
+}
+
+func (q newsFields) fieldset() fieldset {
+	f, _ := parseFields(q.Fields, "news")
+	return f
+}
+
+type sourcesFields struct {
+	Fields string `form:"fields" json:"fields" binding:"max=1000,fields=sources"`
+}
+
+func (q sourcesFields) fieldset() fieldset {
+	f, _ := parseFields(q.Fields, "sources")
+	return f
+}
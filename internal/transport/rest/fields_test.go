@@ -0,0 +1,129 @@
+package rest
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	model "agregator/api/internal/model/db"
+)
+
+func TestParseFields(t *testing.T) {
+	tests := []struct {
+		name    string
+		fields  string
+		kind    string
+		want    string // JSON дерева полей; null — все поля
+		unknown string
+	}{
+		{name: "empty means all", fields: "", kind: "list", want: "null"},
+		{name: "full preset", fields: "id,full", kind: "list", want: "null"},
+		{name: "plain fields", fields: "id, title", kind: "list", want: `{"id":null,"title":null}`},
+		{name: "nested field", fields: "source.logoUrl", kind: "list", want: `{"source":{"logoUrl":null}}`},
+		{name: "whole object wins over nested", fields: "source.logoUrl,source", kind: "list", want: `{"source":null}`},
+		{name: "preset with extra field", fields: "card,sources.full_text", kind: "news",
+			want: `{"category":null,"date":null,"enclosure":null,"id":null,"primaryFeedId":null,` +
+				`"sources":{"full_text":null,"id":null,"link":null,"name":null,"primary":null,"pubDate":null,` +
+				`"source":{"displayName":null,"logoUrl":null},"title":null},"storyId":null,"title":null,"viewsCount":null}`},
+		{name: "unknown field", fields: "id,foo", kind: "list", unknown: "foo"},
+		{name: "unknown nested field", fields: "source.foo", kind: "list", unknown: "source.foo"},
+		{name: "nested into a leaf", fields: "title.x", kind: "list", unknown: "title.x"},
+		{name: "preset of another kind", fields: "items.id", kind: "list", unknown: "items.id"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, unknown := parseFields(tt.fields, tt.kind)
+			if unknown != tt.unknown {
+				t.Fatalf("unknown = %q, want %q", unknown, tt.unknown)
+			}
+			if tt.unknown != "" {
+				return
+			}
+			data, _ := json.Marshal(got)
+			if string(data) != tt.want {
+				t.Errorf("parseFields(%q) = %s, want %s", tt.fields, data, tt.want)
+			}
+		})
+	}
+}
+
+func TestProject(t *testing.T) {
+	score := 0.75
+	items := []model.List{
+		{ID: 1, Time: time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC), Title: "a", Score: &score,
+			Source: &model.SourceInfo{Name: "tass", DisplayName: "ТАСС"}},
+		{ID: 2, Title: "b"},
+	}
+
+	tests := []struct {
+		name   string
+		fields string
+		want   string
+	}{
+		{"all fields", "",
+			`[{"id":1,"date":"2026-10-01T00:00:00Z","title":"a","isRT":false,"sourceName":"","viewsCount":0,` +
+				`"source":{"name":"tass","displayName":"ТАСС","logoUrl":{"String":"","Valid":false},"siteUrl":{"String":"","Valid":false},` +
+				`"region":{"String":"","Valid":false},"language":{"String":"","Valid":false},"priority":0,"trustLevel":0},"score":0.75},` +
+				`{"id":2,"date":"0001-01-01T00:00:00Z","title":"b","isRT":false,"sourceName":"","viewsCount":0}]`},
+		{"selected fields", "id,date", `[{"date":"2026-10-01T00:00:00Z","id":1},{"date":"0001-01-01T00:00:00Z","id":2}]`},
+		// omitempty сохраняется: пустые score и source не появляются как null
+		{"omitempty fields", "id,score,source.displayName", `[{"id":1,"score":0.75,"source":{"displayName":"ТАСС"}},{"id":2}]`},
+		{"null string stays an object", "source.logoUrl", `[{"source":{"logoUrl":{"String":"","Valid":false}}},{}]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields, unknown := parseFields(tt.fields, "list")
+			if unknown != "" {
+				t.Fatalf("unknown field %q", unknown)
+			}
+			data, err := json.Marshal(fields.project(items))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("project(%q) =\n%s\nwant\n%s", tt.fields, data, tt.want)
+			}
+		})
+	}
+}
+
+func TestProjectNews(t *testing.T) {
+	news := model.News{
+		ID:       7,
+		Title:    "t",
+		FullText: model.NullString{},
+		Sources:  []model.Source{{ID: 1, Title: "s1", Primary: true}, {ID: 2, Title: "s2"}},
+	}
+	fields, _ := parseFields("id,sources.id,sources.primary", "news")
+	data, err := json.Marshal(fields.project(news))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"id":7,"sources":[{"id":1,"primary":true},{"id":2,"primary":false}]}`; string(data) != want {
+		t.Errorf("project() = %s, want %s", data, want)
+	}
+}
+
+func TestNewsColumns(t *testing.T) {
+	tests := []struct {
+		fields              string
+		rewrite, sourceText bool
+	}{
+		{"", true, true},
+		{"full", true, true},
+		{"card", false, false},
+		{"card,rewrite", true, false},
+		{"sources", false, true},
+		{"sources.full_text", false, true},
+		{"sources.id", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fields, func(t *testing.T) {
+			f, _ := parseFields(tt.fields, "news")
+			got := f.newsColumns()
+			if got.Rewrite != tt.rewrite || got.SourceText != tt.sourceText {
+				t.Errorf("newsColumns() = %+v, want Rewrite %v, SourceText %v", got, tt.rewrite, tt.sourceText)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package rest
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	model "agregator/api/internal/model/db"
+	"agregator/api/internal/service/db"
+)
+
+// GetGroupSources возвращает источники группы постранично, новые первыми.
+// Следующая страница запрашивается с cursor из nextCursor.
+func (a *API) GetGroupSources(c *gin.Context) {
+	var param idParam
+	if err := bindURI(c, &param); err != nil {
+		c.Error(err)
+		return
+	}
+	var query sourcesQuery
+	if err := bindQuery(c, &query); err != nil {
+		c.Error(err)
+		return
+	}
+	var cursor *db.SourceCursor
+	if query.Cursor != "" {
+		parsed, err := db.ParseSourceCursor(query.Cursor)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		cursor = &parsed
+	}
+	fields := query.fieldset()
+	withText := fields.wants("items.full_text")
+
+	key := "clusters:sources:" + strconv.FormatUint(param.ID, 10) + ":" + query.Cursor + ":" + strconv.FormatUint(query.Limit, 10)
+	if !withText {
+		key += ":notext"
+	}
+	page, err := cached(a, c, key, 10*time.Minute, func(ctx context.Context) (model.SourcePage, error) {
+		return a.db.GetGroupSources(ctx, param.ID, cursor, query.Limit, withText)
+	})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(200, fields.project(page))
+}
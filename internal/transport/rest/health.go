@@ -0,0 +1,115 @@
+package rest
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"agregator/api/internal/pkg/config"
+	"agregator/api/internal/service/redis"
+)
+
+type dependencyStatus struct {
+	Status    string `json:"status"` // up или down
+	LatencyMs int64  `json:"latencyMs"`
+	Error     string `json:"error,omitempty"`
+}
+
+type readiness struct {
+	Status     string                      `json:"status"` // ok, degraded или unavailable
+	Checks     map[string]dependencyStatus `json:"checks"`
+	ViewsFlush *time.Time                  `json:"lastViewsFlush"`
+	DBBreaker  string                      `json:"dbBreaker"` // Состояние предохранителя БД: closed, open, half-open
+	Cache      redis.Stats                 `json:"cache"`
+	Migration  migrationStatus             `json:"migration"`
+}
+
+type migrationStatus struct {
+	Version int64  `json:"version"`
+	Dirty   bool   `json:"dirty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// Healthz — проверка живости: процесс запущен и обрабатывает запросы.
+// Зависимости здесь намеренно не проверяются, чтобы Kubernetes не перезапускал
+// под из-за недоступности Postgres или Redis.
+func (a *API) Healthz(c *gin.Context) {
+	c.JSON(200, gin.H{"status": "ok"})
+}
+
+// Readyz — проверка готовности: пингует Postgres и Redis с таймаутом
+// и возвращает статус по каждой зависимости. Без Redis сервис работает
+// в режиме без кэша, а без Postgres — отдает снимки из Redis (X-Stale), поэтому
+// недоступность одной из зависимостей дает статус degraded, а не 503: иначе
+// Kubernetes снял бы поды с трафика как раз тогда, когда они могут отдавать снимки.
+// 503 — только если схема БД не подходит или недоступны обе зависимости.
+func (a *API) Readyz(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), config.Duration("HEALTH_CHECK_TIMEOUT", 2*time.Second))
+	defer cancel()
+
+	checks := map[string]func(context.Context) error{
+		"postgres": a.db.Ping,
+		"redis":    a.cache.Ping,
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	result := readiness{
+		Status:    "ok",
+		Checks:    make(map[string]dependencyStatus, len(checks)),
+		DBBreaker: a.db.BreakerState(),
+		Cache:     a.cache.Stats(),
+	}
+	for name, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			start := time.Now()
+			err := check(ctx)
+			status := dependencyStatus{Status: "up", LatencyMs: time.Since(start).Milliseconds()}
+			if err != nil {
+				status.Status = "down"
+				status.Error = err.Error()
+			}
+			mu.Lock()
+			result.Checks[name] = status
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	if last := a.lastViewsFlush.Load(); last > 0 {
+		t := time.Unix(0, last)
+		result.ViewsFlush = &t
+	}
+
+	schemaOK := true
+	if result.Checks["postgres"].Status == "up" {
+		version, dirty, err := a.db.SchemaVersion(ctx)
+		result.Migration = migrationStatus{Version: version, Dirty: dirty}
+		if err == nil {
+			err = a.db.CheckSchema(ctx)
+		}
+		if err != nil {
+			result.Migration.Error = err.Error()
+			schemaOK = false
+		}
+	}
+
+	code := 200
+	switch {
+	case !schemaOK:
+		// Запросы написаны под другую схему — отвечать нечем
+		result.Status = "unavailable"
+		code = 503
+	case result.Checks["postgres"].Status != "up" && result.Checks["redis"].Status != "up":
+		// Без БД ответы собираются из снимков в Redis; без обоих отдавать нечего
+		result.Status = "unavailable"
+		code = 503
+	case result.Checks["postgres"].Status != "up" || result.Checks["redis"].Status != "up":
+		result.Status = "degraded"
+	}
+	c.JSON(code, result)
+}
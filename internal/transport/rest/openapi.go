@@ -0,0 +1,201 @@
+package rest
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	model "agregator/api/internal/model/db"
+	"agregator/api/internal/pkg/openapi"
+	"agregator/api/internal/transport/middleware"
+)
+
+// Описание API для /api/openapi.json. Каждый маршрут из app.Run должен быть
+// перечислен в Operations: расхождение проверяет тест пакета app (app.CheckRoutes).
+
+const v1 = "/api/v1"
+
+// Тела ответов, которые обработчики собирают через gin.H
+type (
+	listResponse struct {
+		Items []model.List `json:"items"`
+	}
+	categoriesResponse struct {
+		Items []model.CategoryCount `json:"items"`
+	}
+	sourcesResponse struct {
+		Items []model.SourceInfo `json:"items"`
+	}
+	trendingResponse struct {
+		Items []model.TrendingTag `json:"items"`
+	}
+	maxResponse struct {
+		Max uint64 `json:"max"`
+	}
+	pongResponse struct {
+		Message string `json:"message"`
+	}
+	healthResponse struct {
+		Status string `json:"status"`
+	}
+	batchResponse struct {
+		Items   batchItems `json:"items"`
+		Missing []uint64   `json:"missing"` // Идентификаторы, которых нет в БД
+	}
+	// batchItems — model.List или model.News в зависимости от view
+	batchItems []any
+)
+
+// fieldsNote — общее пояснение к параметру fields
+const fieldsNote = "Параметр fields оставляет в ответе только перечисленные поля (вложенные — через точку) " +
+	"или поля пресета card; при этом обязательные по схеме поля могут отсутствовать. " +
+	"Это фильтр ответа: из БД не читаются только не запрошенные полные тексты (rewrite, sources.full_text)."
+
+// Operations — все маршруты сервиса
+var Operations = []openapi.Operation{
+	{Method: "GET", Path: "/healthz", ID: "healthz", Tags: []string{"health"}, Summary: "Проверка живости", Response: healthResponse{}},
+	{Method: "GET", Path: "/readyz", ID: "readyz", Tags: []string{"health"}, Summary: "Проверка готовности: Postgres, Redis, схема",
+		Description: "Отвечает 503, если схема БД не подходит или недоступны и Postgres, и Redis; " +
+			"при недоступности одного из них — 200 со статусом degraded.", Response: readiness{}},
+	{Method: "GET", Path: "/api/ping", ID: "ping", Tags: []string{"health"}, Summary: "Ping", Response: pongResponse{}},
+	{Method: "GET", Path: "/api/openapi.json", ID: "openapi", Tags: []string{"docs"}, Summary: "Этот документ"},
+	{Method: "GET", Path: "/api/docs", ID: "docs", Tags: []string{"docs"}, Summary: "Документация API (Redoc)"},
+	{Method: "GET", Path: v1 + "/max", ID: "getMax", Tags: []string{"groups"}, Summary: "Наибольший идентификатор группы", Response: maxResponse{}},
+	{Method: "GET", Path: v1 + "/get", ID: "getBatch", Tags: []string{"groups"}, Summary: "Группы по списку идентификаторов",
+		Description: "view=list возвращает элементы List, view=news — News. " + fieldsNote,
+		Params:      []any{batchQuery{}}, Response: batchResponse{}},
+	{Method: "POST", Path: v1 + "/get", ID: "postBatch", Tags: []string{"groups"}, Summary: "Группы по списку идентификаторов (для длинных списков)",
+		Description: "view=list возвращает элементы List, view=news — News. " + fieldsNote,
+		Body:        batchRequest{}, Response: batchResponse{}},
+	{Method: "GET", Path: v1 + "/get/all", ID: "getList", Tags: []string{"groups"}, Summary: "Лента групп до date",
+		Description: fieldsNote, Params: []any{listQuery{}}, Response: listResponse{}},
+	{Method: "GET", Path: v1 + "/get/top", ID: "getTop", Tags: []string{"groups"}, Summary: "Группы с наибольшим числом источников",
+		Description: "По умолчанию — за последние 27 часов. " + fieldsNote, Params: []any{topQuery{}}, Response: listResponse{}},
+	{Method: "GET", Path: v1 + "/get/reg", ID: "getRT", Tags: []string{"groups"}, Summary: "Группы RT или остальные",
+		Description: fieldsNote, Params: []any{rtQuery{}}, Response: listResponse{}},
+	{Method: "GET", Path: v1 + "/get/similar/:id", ID: "getSimilar", Tags: []string{"groups"}, Summary: "Похожие группы",
+		Description: fieldsNote, Params: []any{idParam{}, similarQuery{}}, Response: listResponse{}},
+	{Method: "GET", Path: v1 + "/get/:id", ID: "getByID", Tags: []string{"groups"}, Summary: "Группа с источниками",
+		Description: "При sources_limit возвращаются первые источники, а остальные — через /get/{id}/sources с sourcesCursor. " + fieldsNote,
+		Params:      []any{idParam{}, newsQuery{}}, Response: model.News{}},
+	{Method: "GET", Path: v1 + "/get/:id/timeline", ID: "getTimeline", Tags: []string{"groups"}, Summary: "Развитие освещения группы",
+		Params: []any{idParam{}}, Response: model.Coverage{}},
+	{Method: "GET", Path: v1 + "/get/:id/sources", ID: "getGroupSources", Tags: []string{"groups"}, Summary: "Источники группы постранично",
+		Description: "Следующая страница запрашивается с cursor из nextCursor. " + fieldsNote,
+		Params:      []any{idParam{}, sourcesQuery{}}, Response: model.SourcePage{}},
+	{Method: "GET", Path: v1 + "/categories", ID: "getCategories", Tags: []string{"categories"}, Summary: "Число групп по рубрикам",
+		Params: []any{categoriesQuery{}}, Response: categoriesResponse{}},
+	{Method: "GET", Path: v1 + "/search/semantic", ID: "searchSemantic", Tags: []string{"search"}, Summary: "Семантический или гибридный поиск",
+		Description: "Отвечает 503, если эмбеддер не настроен. " + fieldsNote, Params: []any{semanticQuery{}}, Response: listResponse{}},
+	{Method: "GET", Path: v1 + "/stories/:id", ID: "getStory", Tags: []string{"stories"}, Summary: "Сюжет и хронология его групп",
+		Params: []any{idParam{}}, Response: model.Story{}},
+	{Method: "GET", Path: v1 + "/tags/trending", ID: "getTrendingTags", Tags: []string{"tags"}, Summary: "Набирающие популярность теги",
+		Params: []any{trendingQuery{}}, Response: trendingResponse{}},
+	{Method: "GET", Path: v1 + "/tags/:tag/groups", ID: "getTagGroups", Tags: []string{"tags"}, Summary: "Группы с тегом",
+		Description: fieldsNote, Params: []any{tagParam{}, tagGroupsQuery{}}, Response: listResponse{}},
+	{Method: "GET", Path: v1 + "/sources", ID: "getSources", Tags: []string{"sources"}, Summary: "Реестр источников", Response: sourcesResponse{}},
+	{Method: "GET", Path: v1 + "/sources/:name/groups", ID: "getSourceGroups", Tags: []string{"sources"}, Summary: "Группы источника",
+		Description: fieldsNote, Params: []any{sourceParam{}, sourceGroupsQuery{}}, Response: listResponse{}},
+}
+
+// fieldDescriptions поясняют поля, которые называются не так, как в БД, или ведут себя неочевидно
+var fieldDescriptions = map[string]string{
+	"List.date":             "Время создания группы",
+	"List.sourceName":       "Имя основного источника (feed.source_name)",
+	"List.viewsCount":       "Просмотры: сохраненные в БД плюс еще не сброшенные из кэша",
+	"List.score":            "Сходство с исходной группой; только в /get/similar и поиске",
+	"News.title":            "Заголовок группы; если пуст — заголовок основного источника",
+	"News.date":             "Время создания группы",
+	"News.rewrite":          "Полный текст группы (переписанный); Valid = false, если его нет или он не запрошен в fields",
+	"News.primaryFeedId":    "Идентификатор основного источника; у него в sources primary = true",
+	"News.sources":          "Источники, новые первыми: все или первые sources_limit",
+	"News.sourcesTotal":     "Сколько всего источников в группе",
+	"News.sourcesCursor":    "Курсор для /get/{id}/sources, если вернулись не все источники",
+	"Source.pubDate":        "Время публикации источника",
+	"Source.name":           "Имя источника (feed.source_name)",
+	"Source.full_text":      "Полный текст источника; Valid = false, если его нет или он не запрошен в fields",
+	"Source.primary":        "Основной (представительный) источник группы",
+	"SourcePage.nextCursor": "Курсор следующей страницы; отсутствует на последней",
+}
+
+// openAPISpec — параметры генерации; правила binding этого пакета переводятся в ограничения схемы
+func openAPISpec() openapi.Spec {
+	return openapi.Spec{
+		Title:   "Agregator API",
+		Version: "1",
+		Problem: middleware.Problem{},
+		Types: map[reflect.Type]*openapi.Schema{
+			// Формат sql.NullString: при Valid = false значение String не используется
+			reflect.TypeFor[model.NullString](): {
+				Type:       "object",
+				Properties: map[string]*openapi.Schema{"String": {Type: "string"}, "Valid": {Type: "boolean"}},
+				Required:   []string{"String", "Valid"},
+			},
+			reflect.TypeFor[batchItems](): {Type: "array", Items: &openapi.Schema{AnyOf: []*openapi.Schema{
+				{Ref: "#/components/schemas/List"},
+				{Ref: "#/components/schemas/News"},
+			}}},
+		},
+		Rules: map[string]func(string, *openapi.Schema){
+			"maxlimit": func(name string, s *openapi.Schema) {
+				max := int(limitMaxima[name])
+				if s.Type == "array" {
+					s.MaxItems = &max
+					return
+				}
+				v := float64(max)
+				s.Maximum = &v
+			},
+			"maxitems": func(param string, s *openapi.Schema) {
+				if max, err := strconv.Atoi(param); err == nil {
+					s.MaxItems = &max
+				}
+			},
+			"itemmax": func(param string, s *openapi.Schema) {
+				if max, err := strconv.Atoi(param); err == nil && s.Items != nil {
+					s.Items.MaxLength = &max
+				}
+			},
+			"window": func(_ string, s *openapi.Schema) {
+				s.Description = "Длительность вида 6h, 90m или 3d, не больше " + maxWindow.String()
+			},
+			"fields": func(kind string, s *openapi.Schema) {
+				s.Description = "Поля через запятую; допустимы: " + fieldNames(kind)
+			},
+		},
+		Descriptions: fieldDescriptions,
+	}
+}
+
+// OpenAPI возвращает документ OpenAPI в JSON с учетом текущих настроек лимитов
+func OpenAPI() ([]byte, error) {
+	loadLimits()
+	return json.MarshalIndent(openapi.Build(openAPISpec(), Operations), "", "  ")
+}
+
+// GetOpenAPI отдает документ OpenAPI
+func (a *API) GetOpenAPI(c *gin.Context) {
+	c.Header("Access-Control-Allow-Origin", "*")
+	c.Data(200, "application/json; charset=utf-8", a.openapi)
+}
+
+// docsPage — Redoc, загружающий /api/openapi.json
+const docsPage = `<!DOCTYPE html>
+<html>
+<head>
+  <meta charset="utf-8">
+  <title>Agregator API</title>
+  <meta name="viewport" content="width=device-width, initial-scale=1">
+</head>
+<body>
+  <redoc spec-url="openapi.json"></redoc>
+  <script src="https://cdn.redoc.ly/redoc/v2.1.5/bundles/redoc.standalone.js"></script>
+</body>
+</html>`
+
+// GetDocs отдает страницу документации API
+func (a *API) GetDocs(c *gin.Context) {
+	c.Data(200, "text/html; charset=utf-8", []byte(docsPage))
+}
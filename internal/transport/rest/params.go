@@ -0,0 +1,358 @@
+package rest
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+
+	"agregator/api/internal/pkg/config"
+	"agregator/api/internal/service/db"
+	"agregator/api/internal/transport/middleware"
+)
+
+// Параметры запросов описываются структурами с тегами form/uri и binding.
+// maxlimit=<name> ограничивает значение максимумом из MAX_LIMIT_<NAME>.
+
+type idParam struct {
+	ID uint64 `uri:"id" binding:"min=1"`
+}
+
+type newsQuery struct {
+	newsFields
+	SourcesLimit uint64 `form:"sources_limit" binding:"maxlimit=sources"` // 0 — все источники
+}
+
+// sourcesQuery — страница источников группы; cursor берется из nextCursor или sourcesCursor
+type sourcesQuery struct {
+	sourcesFields
+	Limit  uint64 `form:"limit,default=20" binding:"min=1,maxlimit=sources"`
+	Cursor string `form:"cursor" binding:"max=200"`
+}
+
+// filterQuery — фильтр по источникам и рубрикам; значения передаются повтором параметра или через запятую.
+// maxitems и itemmax проверяют значения уже после разбора по запятым (см. splitValues).
+type filterQuery struct {
+	Sources        []string `form:"source" binding:"maxitems=50,itemmax=200"`
+	ExcludeSources []string `form:"exclude_source" binding:"maxitems=50,itemmax=200"`
+	Categories     []string `form:"category" binding:"maxitems=20,itemmax=50"`
+}
+
+// filter приводит параметры к db.ListFilter
+func (f filterQuery) filter() db.ListFilter {
+	return db.ListFilter{
+		Sources:        splitValues(f.Sources),
+		ExcludeSources: splitValues(f.ExcludeSources),
+		Categories:     splitValues(f.Categories),
+	}
+}
+
+// timeRange — границы по времени: from/to (RFC3339, to не включается) или window
+// вида 6h, 3d. window вместе с to отсчитывается от to, вместе с from не допускается.
+type timeRange struct {
+	From   time.Time `form:"from"`
+	To     time.Time `form:"to"`
+	Window string    `form:"window" binding:"omitempty,window"`
+}
+
+// apply переносит границы в фильтр, проверяя их согласованность
+func (r timeRange) apply(f *db.ListFilter) error {
+	var fields []middleware.FieldError
+	if r.Window != "" && !r.From.IsZero() {
+		fields = append(fields, middleware.FieldError{Field: "window", Message: "cannot be combined with from"})
+	}
+	if !r.From.IsZero() && !r.To.IsZero() && !r.From.Before(r.To) {
+		fields = append(fields, middleware.FieldError{Field: "to", Message: "must be after from"})
+	}
+	if len(fields) > 0 {
+		return &middleware.ValidationError{Fields: fields}
+	}
+
+	f.From, f.To = r.From, r.To
+	if r.Window != "" {
+		window, _ := parseWindow(r.Window)
+		if r.To.IsZero() {
+			f.Window = window
+		} else {
+			f.From = r.To.Add(-window)
+		}
+	}
+	return nil
+}
+
+// archived сообщает, что диапазон целиком в прошлом и результат почти не меняется
+func (r timeRange) archived() bool {
+	return !r.To.IsZero() && time.Since(r.To) > 24*time.Hour
+}
+
+// dateQuery — граница ленты: возвращаются группы старше Date
+type dateQuery struct {
+	Date time.Time `form:"date"` // RFC3339; по умолчанию — текущее время
+}
+
+// before возвращает границу ленты и ее часть ключа кэша; без date — текущее время и пустой ключ
+func (q dateQuery) before() (time.Time, string) {
+	if q.Date.IsZero() {
+		return time.Now(), ""
+	}
+	return q.Date, q.Date.Format(time.RFC3339)
+}
+
+type listQuery struct {
+	filterQuery
+	listFields
+	dateQuery
+	Limit uint64 `form:"limit,default=15" binding:"min=1,maxlimit=list"`
+	Query string `form:"q" binding:"max=200"` // Поисковые фразы через запятую
+}
+
+type topQuery struct {
+	filterQuery
+	listFields
+	timeRange
+	Limit uint64 `form:"limit,default=15" binding:"min=1,maxlimit=list"`
+}
+
+type rtQuery struct {
+	filterQuery
+	listFields
+	timeRange
+	Limit uint64 `form:"limit,default=15" binding:"min=1,maxlimit=list"`
+	RT    bool   `form:"rt,default=true"`
+}
+
+type similarQuery struct {
+	filterQuery
+	listFields
+	Limit     uint64   `form:"limit,default=10" binding:"min=1,maxlimit=similar"`
+	MinScore  *float64 `form:"min_score" binding:"omitempty,min=-1,max=1"` // Косинусное сходство
+	MaxAge    string   `form:"max_age" binding:"omitempty,window"`         // Как window: 12h, 7d
+	ExcludeRT bool     `form:"exclude_rt"`
+	MMR       bool     `form:"mmr"`
+	Lambda    float64  `form:"mmr_lambda,default=0.7" binding:"min=0,max=1"`
+}
+
+// options приводит параметры к db.SimilarOptions
+func (q similarQuery) options() db.SimilarOptions {
+	maxAge, _ := parseWindow(q.MaxAge)
+	return db.SimilarOptions{
+		MinScore:  q.MinScore,
+		MaxAge:    maxAge,
+		ExcludeRT: q.ExcludeRT,
+		MMR:       q.MMR,
+		Lambda:    q.Lambda,
+	}
+}
+
+// splitValues разбирает значения вида a,b и отбрасывает пустые
+func splitValues(values []string) []string {
+	var out []string
+	for _, v := range values {
+		for _, part := range strings.Split(v, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				out = append(out, part)
+			}
+		}
+	}
+	return out
+}
+
+// parseWindow разбирает длительность в формате time.ParseDuration с дополнительным суффиксом d (сутки)
+func parseWindow(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.ParseUint(days, 10, 16)
+		if err != nil {
+			return 0, err
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(s)
+}
+
+// maxWindow — наибольшее допустимое значение window; переопределяется через MAX_WINDOW
+var maxWindow = 31 * 24 * time.Hour
+
+// limitMaxima — верхние границы limit по умолчанию; переопределяются через MAX_LIMIT_<NAME>
+var limitMaxima = map[string]uint64{
+	"list":    100,
+	"similar": 50,
+	"batch":   100,
+	"sources": 100,
+}
+
+// loadLimits читает максимумы limit и window из окружения
+func loadLimits() {
+	for name, def := range limitMaxima {
+		limitMaxima[name] = config.Uint("MAX_LIMIT_"+strings.ToUpper(name), def)
+	}
+	maxWindow = config.Duration("MAX_WINDOW", maxWindow)
+}
+
+// registerValidators настраивает валидатор Gin: имена полей в ошибках берутся
+// из тегов form/uri, а maxlimit проверяет настраиваемые максимумы.
+// maxitems и itemmax ограничивают число и длину значений списка после разбора по запятым.
+func registerValidators() error {
+	loadLimits()
+
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return errors.New("unexpected validator engine")
+	}
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		for _, tag := range []string{"form", "uri", "json"} {
+			if name, _, _ := strings.Cut(field.Tag.Get(tag), ","); name != "" && name != "-" {
+				return name
+			}
+		}
+		return field.Name
+	})
+	err := v.RegisterValidation("maxlimit", func(fl validator.FieldLevel) bool {
+		max, ok := limitMaxima[fl.Param()]
+		if fl.Field().Kind() == reflect.Slice {
+			return ok && uint64(fl.Field().Len()) <= max
+		}
+		return ok && fl.Field().Uint() <= max
+	})
+	if err != nil {
+		return err
+	}
+	err = v.RegisterValidation("maxitems", func(fl validator.FieldLevel) bool {
+		max, err := strconv.Atoi(fl.Param())
+		values, ok := fl.Field().Interface().([]string)
+		return err == nil && ok && len(splitValues(values)) <= max
+	})
+	if err != nil {
+		return err
+	}
+	err = v.RegisterValidation("itemmax", func(fl validator.FieldLevel) bool {
+		max, err := strconv.Atoi(fl.Param())
+		values, ok := fl.Field().Interface().([]string)
+		if err != nil || !ok {
+			return false
+		}
+		for _, value := range splitValues(values) {
+			if utf8.RuneCountInString(value) > max {
+				return false
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	err = v.RegisterValidation("window", func(fl validator.FieldLevel) bool {
+		window, err := parseWindow(fl.Field().String())
+		return err == nil && window > 0 && window <= maxWindow
+	})
+	if err != nil {
+		return err
+	}
+	return v.RegisterValidation("fields", func(fl validator.FieldLevel) bool {
+		_, unknown := parseFields(fl.Field().String(), fl.Param())
+		return unknown == ""
+	})
+}
+
+// bindQuery заполняет dst из query-параметров и приводит ошибки к ValidationError
+func bindQuery(c *gin.Context, dst any) error {
+	return bindingError(c, c.ShouldBindQuery(dst))
+}
+
+// bindURI заполняет dst из параметров пути и приводит ошибки к ValidationError
+func bindURI(c *gin.Context, dst any) error {
+	return bindingError(c, c.ShouldBindUri(dst))
+}
+
+func bindingError(c *gin.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var verrs validator.ValidationErrors
+	if errors.As(err, &verrs) {
+		fields := make([]middleware.FieldError, 0, len(verrs))
+		for _, e := range verrs {
+			fields = append(fields, middleware.FieldError{Field: e.Field(), Message: fieldMessage(e)})
+		}
+		return &middleware.ValidationError{Fields: fields}
+	}
+
+	// Ошибки разбора значений Gin не привязывает к полю, поэтому ищем параметр по значению
+	var value string
+	var numErr *strconv.NumError
+	var timeErr *time.ParseError
+	switch {
+	case errors.As(err, &numErr):
+		value = numErr.Num
+	case errors.As(err, &timeErr):
+		value = timeErr.Value
+	}
+	if field := paramByValue(c, value); field != "" {
+		return &middleware.ValidationError{Fields: []middleware.FieldError{{Field: field, Message: "has invalid format"}}}
+	}
+	return fmt.Errorf("%w: malformed parameters", db.ErrInvalidInput)
+}
+
+func paramByValue(c *gin.Context, value string) string {
+	if value == "" {
+		return ""
+	}
+	for key, vals := range c.Request.URL.Query() {
+		for _, v := range vals {
+			if v == value {
+				return key
+			}
+		}
+	}
+	for _, p := range c.Params {
+		if p.Value == value {
+			return p.Key
+		}
+	}
+	return ""
+}
+
+func fieldMessage(e validator.FieldError) string {
+	switch e.Tag() {
+	case "required":
+		return "is required"
+	case "min":
+		if e.Kind() == reflect.String {
+			return "must be at least " + e.Param() + " characters long"
+		}
+		if e.Kind() == reflect.Slice {
+			return "must contain at least " + e.Param() + " items"
+		}
+		return "must be at least " + e.Param()
+	case "max":
+		if e.Kind() == reflect.String {
+			return "must be at most " + e.Param() + " characters long"
+		}
+		return "must be at most " + e.Param()
+	case "maxlimit":
+		if e.Kind() == reflect.Slice {
+			return "must contain at most " + strconv.FormatUint(limitMaxima[e.Param()], 10) + " items"
+		}
+		return "must be at most " + strconv.FormatUint(limitMaxima[e.Param()], 10)
+	case "maxitems":
+		return "must contain at most " + e.Param() + " items"
+	case "itemmax":
+		return "items must be at most " + e.Param() + " characters long"
+	case "oneof":
+		return "must be one of: " + strings.ReplaceAll(e.Param(), " ", ", ")
+	case "fields":
+		_, unknown := parseFields(fmt.Sprint(e.Value()), e.Param())
+		return "has unknown field " + strconv.Quote(unknown) + "; top-level fields and presets: " + fieldNames(e.Param())
+	case "window":
+		return "must be a positive duration like 6h or 3d, at most " + maxWindow.String()
+	default:
+		return "is invalid"
+	}
+}
@@ -0,0 +1,158 @@
+package rest
+
+import (
+	"errors"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"agregator/api/internal/service/db"
+	"agregator/api/internal/transport/middleware"
+)
+
+var validatorsOnce sync.Once
+
+// testContext возвращает контекст Gin с запросом GET /?query и параметрами пути params
+func testContext(t *testing.T, query string, params ...gin.Param) *gin.Context {
+	t.Helper()
+	validatorsOnce.Do(func() {
+		gin.SetMode(gin.TestMode)
+		if err := registerValidators(); err != nil {
+			t.Fatal(err)
+		}
+	})
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("GET", "/?"+query, nil)
+	c.Params = params
+	return c
+}
+
+// fieldErrors возвращает ошибки по полям или nil, если err не ValidationError
+func fieldErrors(err error) []middleware.FieldError {
+	var verr *middleware.ValidationError
+	if errors.As(err, &verr) {
+		return verr.Fields
+	}
+	return nil
+}
+
+func TestBindQuery(t *testing.T) {
+	values := func(n int) string {
+		parts := make([]string, n)
+		for i := range parts {
+			parts[i] = "s" + strconv.Itoa(i)
+		}
+		return strings.Join(parts, ",")
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		want  []middleware.FieldError // nil — запрос корректен
+	}{
+		{"defaults", "", nil},
+		{"malformed int", "limit=abc", []middleware.FieldError{{Field: "limit", Message: "has invalid format"}}},
+		{"malformed time", "date=yesterday", []middleware.FieldError{{Field: "date", Message: "has invalid format"}}},
+		{"limit too small", "limit=0", []middleware.FieldError{{Field: "limit", Message: "must be at least 1"}}},
+		{"limit too large", "limit=101", []middleware.FieldError{{Field: "limit", Message: "must be at most 100"}}},
+		{"query too long", "q=" + strings.Repeat("я", 201), []middleware.FieldError{{Field: "q", Message: "must be at most 200 characters long"}}},
+		// Ограничения списка проверяются после разбора по запятым
+		{"items within limit", "source=" + values(50), nil},
+		{"too many items in one value", "source=" + values(51), []middleware.FieldError{{Field: "source", Message: "must contain at most 50 items"}}},
+		{"too many items across values", "source=" + values(30) + "&source=" + values(21), []middleware.FieldError{{Field: "source", Message: "must contain at most 50 items"}}},
+		{"empty items are not counted", "source=" + values(50) + ",,", nil},
+		{"item too long", "category=a," + strings.Repeat("b", 51), []middleware.FieldError{{Field: "category", Message: "items must be at most 50 characters long"}}},
+		{"unknown field", "fields=id,foo", []middleware.FieldError{{Field: "fields", Message: `has unknown field "foo"; top-level fields and presets: ` + fieldNames("list")}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var q listQuery
+			err := bindQuery(testContext(t, tt.query), &q)
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("bindQuery(%q) = %v, want nil", tt.query, err)
+				}
+				return
+			}
+			if got := fieldErrors(err); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("bindQuery(%q) fields = %+v, want %+v (error %v)", tt.query, got, tt.want, err)
+			}
+		})
+	}
+}
+
+func TestBindQueryWindow(t *testing.T) {
+	tests := []struct {
+		query string
+		ok    bool
+	}{
+		{"window=6h", true},
+		{"window=3d", true},
+		{"window=5x", false},
+		{"window=-1h", false},
+		{"window=32d", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			var q topQuery
+			err := bindQuery(testContext(t, tt.query), &q)
+			if tt.ok != (err == nil) {
+				t.Fatalf("bindQuery(%q) = %v, want ok %v", tt.query, err, tt.ok)
+			}
+			if fields := fieldErrors(err); !tt.ok && (len(fields) != 1 || fields[0].Field != "window") {
+				t.Errorf("bindQuery(%q) fields = %+v, want a window error", tt.query, fields)
+			}
+		})
+	}
+}
+
+func TestBindURI(t *testing.T) {
+	tests := []struct {
+		id   string
+		want []middleware.FieldError
+	}{
+		{"42", nil},
+		{"abc", []middleware.FieldError{{Field: "id", Message: "has invalid format"}}},
+		{"0", []middleware.FieldError{{Field: "id", Message: "must be at least 1"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			var p idParam
+			err := bindURI(testContext(t, "", gin.Param{Key: "id", Value: tt.id}), &p)
+			if got := fieldErrors(err); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("bindURI(%q) fields = %+v, want %+v (error %v)", tt.id, got, tt.want, err)
+			}
+		})
+	}
+}
+
+func TestBindingErrorUnmatched(t *testing.T) {
+	c := testContext(t, "limit=5")
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{"value not in request", &strconv.NumError{Func: "ParseUint", Num: "abc", Err: strconv.ErrSyntax}},
+		{"no value", errors.New("unsupported type")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := bindingError(c, tt.err)
+			if !errors.Is(err, db.ErrInvalidInput) || fieldErrors(err) != nil {
+				t.Errorf("bindingError(%v) = %v, want ErrInvalidInput", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestSplitValues(t *testing.T) {
+	got := splitValues([]string{"a, b", "", " ,c,,", "d"})
+	if want := []string{"a", "b", "c", "d"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("splitValues() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,101 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	model "agregator/api/internal/model/db"
+	"agregator/api/internal/pkg/ranking"
+	"agregator/api/internal/service/db"
+	"agregator/api/internal/transport/middleware"
+)
+
+// rrfK — сглаживающая константа reciprocal rank fusion
+const rrfK = 60
+
+type semanticQuery struct {
+	filterQuery
+	listFields
+	Query string `form:"q" binding:"required,max=500"`
+	Limit uint64 `form:"limit,default=15" binding:"min=1,maxlimit=list"`
+	Mode  string `form:"mode,default=semantic" binding:"oneof=semantic hybrid"` // hybrid — полнотекстовый ранг + векторное сходство
+}
+
+// SearchSemantic ищет группы по смыслу запроса. В режиме hybrid результаты полнотекстового
+// и векторного поиска объединяются через RRF, а score содержит итоговый балл слияния.
+func (a *API) SearchSemantic(c *gin.Context) {
+	var query semanticQuery
+	if err := bindQuery(c, &query); err != nil {
+		c.Error(err)
+		return
+	}
+	if a.embedder == nil {
+		c.Error(fmt.Errorf("%w: semantic search is not configured", db.ErrUnavailable))
+		return
+	}
+
+	filter := query.filter()
+	key := "search:" + query.Mode + ":" + strconv.FormatUint(query.Limit, 10) + ":" + filter.Key() + ":" + query.Query
+	items, err := cached(a, c, key, 10*time.Minute, func(ctx context.Context) ([]model.List, error) {
+		vec, err := a.embedder.Embed(ctx, query.Query)
+		if err != nil {
+			if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+				return nil, err
+			}
+			a.logger.ErrorContext(ctx, "Error embedding query", "error", err.Error())
+			return nil, fmt.Errorf("%w: embedder: %v", db.ErrUnavailable, err)
+		}
+		if model.Vector(vec).IsZero() {
+			return nil, &middleware.ValidationError{Fields: []middleware.FieldError{{Field: "q", Message: "must contain at least one searchable word"}}}
+		}
+		if query.Mode != "hybrid" {
+			return a.db.SearchSemantic(ctx, vec, query.Limit, filter)
+		}
+		return a.searchHybrid(ctx, query.Query, vec, query.Limit, filter)
+	})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(200, gin.H{"items": query.fieldset().project(a.withListViews(c.Request.Context(), items))})
+}
+
+// searchHybrid берет расширенные выборки обоих поисков и сливает их по рангам
+func (a *API) searchHybrid(ctx context.Context, text string, vec model.Vector, limit uint64, filter db.ListFilter) ([]model.List, error) {
+	candidates := min(limit*3, 200)
+	semantic, err := a.db.SearchSemantic(ctx, vec, candidates, filter)
+	if err != nil {
+		return nil, err
+	}
+	fulltext, err := a.db.SearchText(ctx, text, candidates, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[uint64]model.List, len(semantic)+len(fulltext))
+	ranked := make([][]uint64, 2)
+	for i, list := range [][]model.List{semantic, fulltext} {
+		for _, item := range list {
+			byID[item.ID] = item
+			ranked[i] = append(ranked[i], item.ID)
+		}
+	}
+
+	order, scores := ranking.RRF(rrfK, ranked...)
+	if uint64(len(order)) > limit {
+		order = order[:limit]
+	}
+	items := make([]model.List, len(order))
+	for i, id := range order {
+		item := byID[id]
+		score := scores[id]
+		item.Score = &score
+		items[i] = item
+	}
+	return items, nil
+}
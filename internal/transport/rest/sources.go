@@ -0,0 +1,61 @@
+package rest
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	model "agregator/api/internal/model/db"
+)
+
+type sourceParam struct {
+	Name string `uri:"name" binding:"required,max=200"`
+}
+
+type sourceGroupsQuery struct {
+	filterQuery
+	listFields
+	dateQuery
+	Limit uint64 `form:"limit,default=15" binding:"min=1,maxlimit=list"`
+}
+
+// GetSources возвращает реестр источников с метаданными
+func (a *API) GetSources(c *gin.Context) {
+	items, err := cached(a, c, "sources", 1*time.Hour, func(ctx context.Context) ([]model.SourceInfo, error) {
+		return a.db.GetSources(ctx)
+	})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(200, gin.H{"items": items})
+}
+
+// GetSourceGroups возвращает ленту групп, в которых публиковался источник
+func (a *API) GetSourceGroups(c *gin.Context) {
+	var param sourceParam
+	if err := bindURI(c, &param); err != nil {
+		c.Error(err)
+		return
+	}
+	var query sourceGroupsQuery
+	if err := bindQuery(c, &query); err != nil {
+		c.Error(err)
+		return
+	}
+
+	date, date_key := query.before()
+
+	filter := query.filter()
+	key := "clusters:source:" + param.Name + ":" + date_key + ":" + strconv.FormatUint(query.Limit, 10) + ":" + filter.Key()
+	items, err := cached(a, c, key, 10*time.Minute, func(ctx context.Context) ([]model.List, error) {
+		return a.db.GetBySource(ctx, param.Name, date, query.Limit, filter)
+	})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(200, gin.H{"items": query.fieldset().project(a.withListViews(c.Request.Context(), items))})
+}
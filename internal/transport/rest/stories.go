@@ -0,0 +1,64 @@
+package rest
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	model "agregator/api/internal/model/db"
+	"agregator/api/internal/pkg/config"
+	"agregator/api/internal/service/db"
+)
+
+// GetStory возвращает сюжет и хронологию его групп
+func (a *API) GetStory(c *gin.Context) {
+	var param idParam
+	if err := bindURI(c, &param); err != nil {
+		c.Error(err)
+		return
+	}
+
+	story, err := cached(a, c, "stories:"+strconv.FormatUint(param.ID, 10), 10*time.Minute, func(ctx context.Context) (model.Story, error) {
+		return a.db.GetStory(ctx, param.ID)
+	})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	story.Groups = a.withListViews(c.Request.Context(), story.Groups)
+	c.JSON(200, story)
+}
+
+// linkStories периодически связывает новые группы в сюжеты; STORY_LINK_INTERVAL=0 отключает связывание
+func (a *API) linkStories(ctx context.Context) {
+	interval := config.Duration("STORY_LINK_INTERVAL", 5*time.Minute)
+	if interval <= 0 {
+		return
+	}
+	opts := db.StoryOptions{
+		MinScore: config.Float("STORY_MIN_SCORE", 0.82),
+		Window:   config.Duration("STORY_WINDOW", 48*time.Hour),
+		Lookback: config.Duration("STORY_LOOKBACK", 7*24*time.Hour),
+		Batch:    config.Int("STORY_LINK_BATCH", 500),
+		Timeout:  config.Duration("STORY_LINK_TIMEOUT", time.Minute),
+	}
+
+	ticker := time.NewTicker(interval)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			linked, err := a.db.LinkStories(ctx, opts)
+			if err != nil {
+				a.logger.ErrorContext(ctx, "Error linking stories", "error", err.Error())
+				continue
+			}
+			if linked > 0 {
+				a.logger.InfoContext(ctx, "Linked groups to stories", "count", linked)
+			}
+		}
+	}
+}
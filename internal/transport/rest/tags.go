@@ -0,0 +1,92 @@
+package rest
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	model "agregator/api/internal/model/db"
+	"agregator/api/internal/pkg/keywords"
+	"agregator/api/internal/transport/middleware"
+)
+
+type trendingQuery struct {
+	filterQuery
+	Window string `form:"window,default=24h" binding:"window"`
+	Limit  uint64 `form:"limit,default=20" binding:"min=1,maxlimit=list"`
+}
+
+type tagParam struct {
+	Tag string `uri:"tag" binding:"required,max=200"`
+}
+
+type tagGroupsQuery struct {
+	filterQuery
+	listFields
+	dateQuery
+	Limit uint64 `form:"limit,default=15" binding:"min=1,maxlimit=list"`
+}
+
+// GetTrendingTags возвращает теги, которые чаще всего встречаются в группах за период window
+func (a *API) GetTrendingTags(c *gin.Context) {
+	var query trendingQuery
+	if err := bindQuery(c, &query); err != nil {
+		c.Error(err)
+		return
+	}
+	window, _ := parseWindow(query.Window)
+	filter := query.filter()
+
+	key := "tags:trending:" + window.String() + ":" + strconv.FormatUint(query.Limit, 10) + ":" + filter.Key()
+	items, err := cached(a, c, key, 10*time.Minute, func(ctx context.Context) ([]model.TrendingTag, error) {
+		return a.db.GetTrendingTags(ctx, window, query.Limit, filter)
+	})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(200, gin.H{"items": items})
+}
+
+// tagLookup возвращает тег из URL в виде для точного поиска (нижний регистр, одиночные
+// пробелы) и запасной вариант — тот же текст, приведенный к основам
+func tagLookup(raw string) (tag, fallback string) {
+	return strings.Join(strings.Fields(strings.ToLower(raw)), " "), keywords.Key(raw)
+}
+
+// GetTagGroups возвращает ленту групп с тегом. Тег можно передать как из ответа
+// trending (основы слов, ищутся как есть), так и в обычном написании — тогда он
+// приводится к основам. Повторное стеммирование основ не выполняется: оно не идемпотентно.
+func (a *API) GetTagGroups(c *gin.Context) {
+	var param tagParam
+	if err := bindURI(c, &param); err != nil {
+		c.Error(err)
+		return
+	}
+	var query tagGroupsQuery
+	if err := bindQuery(c, &query); err != nil {
+		c.Error(err)
+		return
+	}
+	tag, fallback := tagLookup(param.Tag)
+	if tag == "" {
+		c.Error(&middleware.ValidationError{Fields: []middleware.FieldError{{Field: "tag", Message: "must not be blank"}}})
+		return
+	}
+
+	date, date_key := query.before()
+	filter := query.filter()
+
+	key := "clusters:tag:" + tag + ":" + date_key + ":" + strconv.FormatUint(query.Limit, 10) + ":" + filter.Key()
+	items, err := cached(a, c, key, 10*time.Minute, func(ctx context.Context) ([]model.List, error) {
+		return a.db.GetByTag(ctx, tag, fallback, date, query.Limit, filter)
+	})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(200, gin.H{"items": query.fieldset().project(a.withListViews(c.Request.Context(), items))})
+}
@@ -0,0 +1,25 @@
+package rest
+
+import "testing"
+
+func TestTagLookup(t *testing.T) {
+	tests := []struct {
+		raw      string
+		tag      string
+		fallback string
+	}{
+		// Обычное написание: точного тега нет, ищется по основам
+		{raw: "Выборы  Президента", tag: "выборы президента", fallback: "выбор президент"},
+		// Тег из ответа trending ищется как есть; повторное стеммирование дало бы другую основу
+		{raw: "соревнован", tag: "соревнован", fallback: "соревнова"},
+		{raw: "  ", tag: "", fallback: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			tag, fallback := tagLookup(tt.raw)
+			if tag != tt.tag || fallback != tt.fallback {
+				t.Errorf("tagLookup(%q) = %q, %q, want %q, %q", tt.raw, tag, fallback, tt.tag, tt.fallback)
+			}
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package rest
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	model "agregator/api/internal/model/db"
+	"agregator/api/internal/pkg/coverage"
+)
+
+// GetTimeline возвращает анализ освещения группы: первый источник, время до N источников,
+// почасовую гистограмму и варианты заголовков
+func (a *API) GetTimeline(c *gin.Context) {
+	var param idParam
+	if err := bindURI(c, &param); err != nil {
+		c.Error(err)
+		return
+	}
+
+	report, err := cached(a, c, "clusters:timeline:"+strconv.FormatUint(param.ID, 10), 10*time.Minute, func(ctx context.Context) (model.Coverage, error) {
+		entries, err := a.db.GetCoverage(ctx, param.ID)
+		if err != nil {
+			return model.Coverage{}, err
+		}
+		return coverage.Analyze(param.ID, entries), nil
+	})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(200, report)
+}
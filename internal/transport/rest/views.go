@@ -0,0 +1,72 @@
+package rest
+
+import (
+	"context"
+
+	model "agregator/api/internal/model/db"
+)
+
+// viewCounts собирает актуальные счетчики просмотров: значение столбца views (запрос
+// по первичному ключу) плюс просмотры, еще не записанные в БД из Redis и буфера в памяти.
+// Значение из закэшированного ответа отстает после каждого сброса просмотров, поэтому
+// используется, только если БД недоступна.
+func (a *API) viewCounts(ctx context.Context, fallback map[uint64]uint64) map[uint64]uint64 {
+	ids := make([]uint64, 0, len(fallback))
+	for id := range fallback {
+		ids = append(ids, id)
+	}
+
+	stored, err := a.db.GetViews(ctx, ids)
+	if err != nil {
+		a.logger.WarnContext(ctx, "Error getting views from database, using cached values", "error", err.Error())
+		stored = fallback
+	}
+	pending, err := a.cache.PendingViews(ctx, ids)
+	if err != nil {
+		a.logger.ErrorContext(ctx, "Error getting pending views from cache", "error", err.Error())
+	}
+
+	result := make(map[uint64]uint64, len(ids))
+	for _, id := range ids {
+		result[id] = stored[id] + uint64(max(pending[id], 0))
+	}
+	return result
+}
+
+// withListViews проставляет актуальные просмотры элементам списка
+func (a *API) withListViews(ctx context.Context, items []model.List) []model.List {
+	if len(items) == 0 {
+		return items
+	}
+	fallback := make(map[uint64]uint64, len(items))
+	for _, item := range items {
+		fallback[item.ID] = item.ViewsCount
+	}
+	counts := a.viewCounts(ctx, fallback)
+	for i := range items {
+		items[i].ViewsCount = counts[items[i].ID]
+	}
+	return items
+}
+
+// withNewsViews проставляет актуальные просмотры группе
+func (a *API) withNewsViews(ctx context.Context, item model.News) model.News {
+	item.ViewsCount = a.viewCounts(ctx, map[uint64]uint64{item.ID: item.ViewsCount})[item.ID]
+	return item
+}
+
+// withNewsItemsViews проставляет актуальные просмотры нескольким группам одним запросом
+func (a *API) withNewsItemsViews(ctx context.Context, items []model.News) []model.News {
+	if len(items) == 0 {
+		return items
+	}
+	fallback := make(map[uint64]uint64, len(items))
+	for _, item := range items {
+		fallback[item.ID] = item.ViewsCount
+	}
+	counts := a.viewCounts(ctx, fallback)
+	for i := range items {
+		items[i].ViewsCount = counts[items[i].ID]
+	}
+	return items
+}